@@ -0,0 +1,338 @@
+// Package colorx provides perceptual color operations (mixing, lightening,
+// darkening, saturation, contrast) implemented in Oklab space, plus the
+// xterm ANSI256 <-> hex conversion table colors degrade through elsewhere
+// in the theme package. Oklab interpolates and blends far more naturally
+// than sRGB: mixing two hues doesn't pass through a muddy gray midpoint,
+// and lightening/darkening preserves hue instead of just scaling channels.
+package colorx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Mix blends a and b in Oklab space and returns the result as a hex
+// lipgloss.Color. t is clamped to [0,1]; t=0 returns a, t=1 returns b.
+func Mix(a, b lipgloss.TerminalColor, t float64) lipgloss.Color {
+	t = clamp01(t)
+	la := toOklab(hexOf(a))
+	lb := toOklab(hexOf(b))
+	mixed := oklab{
+		L: lerp(la.L, lb.L, t),
+		a: lerp(la.a, lb.a, t),
+		b: lerp(la.b, lb.b, t),
+	}
+	return lipgloss.Color(mixed.toHex())
+}
+
+// Lighten mixes c toward white by amount (clamped to [0,1]).
+func Lighten(c lipgloss.TerminalColor, amount float64) lipgloss.Color {
+	return Mix(c, lipgloss.Color("#FFFFFF"), amount)
+}
+
+// Darken mixes c toward black by amount (clamped to [0,1]).
+func Darken(c lipgloss.TerminalColor, amount float64) lipgloss.Color {
+	return Mix(c, lipgloss.Color("#000000"), amount)
+}
+
+// Saturate scales c's Oklab chroma (the a/b channels) by 1+amount: positive
+// amount pushes the color away from gray, negative amount pulls it toward
+// gray. The result is clamped back into the sRGB gamut.
+func Saturate(c lipgloss.TerminalColor, amount float64) lipgloss.Color {
+	lab := toOklab(hexOf(c))
+	scale := 1 + amount
+	if scale < 0 {
+		scale = 0
+	}
+	lab.a *= scale
+	lab.b *= scale
+	return lipgloss.Color(lab.toHex())
+}
+
+// Contrast returns the WCAG 2.1 contrast ratio between fg and bg (1 for
+// identical luminance, up to 21 for black against white). Use it to check
+// whether a foreground/background pairing is readable (WCAG AA requires
+// >= 4.5 for normal text).
+func Contrast(fg, bg lipgloss.TerminalColor) float64 {
+	l1 := relativeLuminance(hexOf(fg))
+	l2 := relativeLuminance(hexOf(bg))
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// Gradient returns steps evenly-spaced Oklab stops between from and to
+// (inclusive of both ends), for progress bars and sparkline fills. Returns
+// nil if steps < 2.
+func Gradient(from, to lipgloss.TerminalColor, steps int) []lipgloss.Color {
+	if steps < 2 {
+		return nil
+	}
+	stops := make([]lipgloss.Color, steps)
+	for i := 0; i < steps; i++ {
+		t := float64(i) / float64(steps-1)
+		stops[i] = Mix(from, to, t)
+	}
+	return stops
+}
+
+// oklab holds a color's Oklab-space coordinates: L is perceptual
+// lightness (0-1), a/b are the green-red and blue-yellow chroma axes.
+type oklab struct {
+	L, a, b float64
+}
+
+// toOklab converts a "#RRGGBB" hex string to Oklab, per Björn Ottosson's
+// Oklab derivation (sRGB -> linear RGB -> LMS cone response -> cube root ->
+// Oklab).
+func toOklab(hex string) oklab {
+	r, g, b := hexToLinearRGB(hex)
+
+	l := 0.4122214708*r + 0.5363325363*g + 0.0514459929*b
+	m := 0.2119034982*r + 0.6806995451*g + 0.1073969566*b
+	s := 0.0883024619*r + 0.2817188376*g + 0.6299787005*b
+
+	l, m, s = cbrt(l), cbrt(m), cbrt(s)
+
+	return oklab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		a: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		b: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// toHex converts an Oklab color back to a "#RRGGBB" hex string, clamping
+// out-of-gamut results into [0,255] per channel.
+func (c oklab) toHex() string {
+	l := c.L + 0.3963377774*c.a + 0.2158037573*c.b
+	m := c.L - 0.1055613458*c.a - 0.0638541728*c.b
+	s := c.L - 0.0894841775*c.a - 1.2914855480*c.b
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	r := +4.0767416621*l - 3.3077115913*m + 0.2309699292*s
+	g := -1.2684380046*l + 2.6097574011*m - 0.3413193965*s
+	bch := -0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+
+	return hexFromLinearRGB(r, g, bch)
+}
+
+// hexToLinearRGB parses a "#RRGGBB" hex string into linear-light RGB
+// components in [0,1].
+func hexToLinearRGB(hex string) (r, g, b float64) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0
+	}
+	v, err := strconv.ParseInt(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0
+	}
+	r = srgbToLinear(float64(v>>16&0xFF) / 255)
+	g = srgbToLinear(float64(v>>8&0xFF) / 255)
+	b = srgbToLinear(float64(v&0xFF) / 255)
+	return r, g, b
+}
+
+// hexFromLinearRGB converts linear-light RGB components back to a
+// "#RRGGBB" hex string, clamping each channel to [0,1] first.
+func hexFromLinearRGB(r, g, b float64) string {
+	toByte := func(c float64) int {
+		c = linearToSRGB(clamp01(c))
+		return int(math.Round(c * 255))
+	}
+	const hexDigits = "0123456789ABCDEF"
+	hexByte := func(n int) string {
+		return string([]byte{hexDigits[n>>4], hexDigits[n&0xF]})
+	}
+	return "#" + hexByte(toByte(r)) + hexByte(toByte(g)) + hexByte(toByte(b))
+}
+
+// srgbToLinear converts a single sRGB channel (0-1) to linear light.
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB converts a single linear-light channel (0-1) back to sRGB.
+func linearToSRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
+
+// relativeLuminance returns the WCAG relative luminance of a "#RRGGBB" hex
+// color, the weighted sum of its linear RGB components.
+func relativeLuminance(hex string) float64 {
+	r, g, b := hexToLinearRGB(hex)
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+func cbrt(x float64) float64 {
+	if x < 0 {
+		return -math.Cbrt(-x)
+	}
+	return math.Cbrt(x)
+}
+
+func lerp(a, b, t float64) float64 {
+	return a + (b-a)*t
+}
+
+func clamp01(t float64) float64 {
+	if t < 0 {
+		return 0
+	}
+	if t > 1 {
+		return 1
+	}
+	return t
+}
+
+// hexOf resolves tc to a "#RRGGBB" hex string, picking the Dark or Light
+// branch of an Adaptive/CompleteAdaptive color to match
+// lipgloss.DefaultRenderer()'s detected background, and preferring
+// TrueColor on a CompleteColor.
+func hexOf(tc lipgloss.TerminalColor) string {
+	darkBg := lipgloss.DefaultRenderer().HasDarkBackground()
+
+	switch v := tc.(type) {
+	case lipgloss.Color:
+		return hexOfCode(string(v))
+	case lipgloss.AdaptiveColor:
+		if darkBg {
+			return hexOfCode(v.Dark)
+		}
+		return hexOfCode(v.Light)
+	case lipgloss.CompleteColor:
+		return hexOfComplete(v)
+	case lipgloss.CompleteAdaptiveColor:
+		if darkBg {
+			return hexOfComplete(v.Dark)
+		}
+		return hexOfComplete(v.Light)
+	default:
+		return "#000000"
+	}
+}
+
+// hexOfComplete prefers a CompleteColor's TrueColor field, falling back to
+// ANSI256 then ANSI.
+func hexOfComplete(c lipgloss.CompleteColor) string {
+	if c.TrueColor != "" {
+		return hexOfCode(c.TrueColor)
+	}
+	if c.ANSI256 != "" {
+		return hexOfCode(c.ANSI256)
+	}
+	return hexOfCode(c.ANSI)
+}
+
+// hexOfCode normalizes a lipgloss color string ("#7D56F4" or a bare
+// ANSI256 index like "212") to "#RRGGBB".
+func hexOfCode(code string) string {
+	if len(code) > 0 && code[0] == '#' {
+		return code
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return "#000000"
+	}
+	return ANSI256ToHex(n)
+}
+
+// ansi16Hex is the standard xterm low-16 ANSI color palette (indices 0-15).
+var ansi16Hex = [16]string{
+	"#000000", "#800000", "#008000", "#808000",
+	"#000080", "#800080", "#008080", "#C0C0C0",
+	"#808080", "#FF0000", "#00FF00", "#FFFF00",
+	"#0000FF", "#FF00FF", "#00FFFF", "#FFFFFF",
+}
+
+// ansi256CubeSteps are the per-channel levels of xterm's 6x6x6 color cube
+// (indices 16-231): value = 16 + 36*r + 6*g + b, each of r/g/b in [0,5].
+var ansi256CubeSteps = [6]int{0, 95, 135, 175, 215, 255}
+
+// ANSI256ToHex converts an xterm 256-color palette index (0-255) to its hex
+// color, covering the low-16 ANSI colors (0-15), the 6x6x6 color cube
+// (16-231), and the grayscale ramp (232-255). This is the canonical
+// version of the table; theme.ANSI256ToHex delegates here so the rest of
+// the theme package doesn't duplicate it.
+func ANSI256ToHex(code int) string {
+	switch {
+	case code < 0 || code > 255:
+		return "#888888"
+	case code < 16:
+		return ansi16Hex[code]
+	case code < 232:
+		n := code - 16
+		r := ansi256CubeSteps[n/36]
+		g := ansi256CubeSteps[(n/6)%6]
+		b := ansi256CubeSteps[n%6]
+		return rgbHex(r, g, b)
+	default:
+		v := 8 + 10*(code-232)
+		return rgbHex(v, v, v)
+	}
+}
+
+// ANSI16Palette returns a copy of the standard xterm low-16 ANSI color
+// palette (indices 0-15) as hex strings, for callers (e.g. a sixel
+// encoder) that need the whole table rather than just a nearest match.
+func ANSI16Palette() []string {
+	out := make([]string, len(ansi16Hex))
+	copy(out, ansi16Hex[:])
+	return out
+}
+
+// NearestANSI16Hex returns the entry of the 16-color ANSI palette closest to
+// hex in RGB space, for collapsing truecolor/256 palettes down to terminals
+// that only support the low 16 colors.
+func NearestANSI16Hex(hex string) string {
+	tr, tg, tb, ok := hexToRGB(hex)
+	if !ok {
+		return hex
+	}
+
+	best := ansi16Hex[0]
+	bestDist := 1 << 30
+	for _, candidate := range ansi16Hex {
+		r, g, b, _ := hexToRGB(candidate)
+		dr, dg, db := r-tr, g-tg, b-tb
+		dist := dr*dr + dg*dg + db*db
+		if dist < bestDist {
+			bestDist = dist
+			best = candidate
+		}
+	}
+	return best
+}
+
+// hexToRGB parses a "#RRGGBB" string into its integer red/green/blue
+// components (0-255).
+func hexToRGB(hex string) (r, g, b int, ok bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseInt(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF), true
+}
+
+func rgbHex(r, g, b int) string {
+	const hexDigits = "0123456789ABCDEF"
+	byteHex := func(n int) string {
+		return string([]byte{hexDigits[n>>4&0xF], hexDigits[n&0xF]})
+	}
+	return "#" + byteHex(r) + byteHex(g) + byteHex(b)
+}
@@ -3,13 +3,24 @@ package theme
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
+func init() {
+	// Honor AGENTUI_THEME at startup, after charm.go's init has registered
+	// the built-in themes and set the default Current. A bad value
+	// shouldn't prevent the program from starting, so just report it.
+	if err := LoadThemeFromEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "theme: %v\n", err)
+	}
+}
+
 // ThemeJSON represents a theme definition in JSON format.
 // This allows users to create custom themes without writing Go code.
 type ThemeJSON struct {
@@ -19,32 +30,52 @@ type ThemeJSON struct {
 	Author      string     `json:"author,omitempty"`
 	Version     string     `json:"version,omitempty"`
 	Colors      ColorsJSON `json:"colors"`
+
+	// Palette is an optional named color table (e.g. {"purple": "#7D56F4"})
+	// that Colors and Syntax entries can reference by name instead of
+	// repeating a literal color, so a theme can define its hues once and
+	// reuse them across the UI palette and syntax highlighting.
+	Palette map[string]string `json:"palette,omitempty"`
+
+	// Syntax maps Chroma token class names (e.g. "Keyword", "NameFunction",
+	// "LiteralNumber" - the Go identifiers from the chroma package) to a
+	// Palette name or a literal Chroma style-entry string. It's resolved
+	// into ChromaOverrides at load time, alongside chromaOverrides below,
+	// so a theme can be shipped as a single JSON file without recompiling.
+	Syntax map[string]string `json:"syntax,omitempty"`
+
+	// ChromaOverrides tweaks individual Chroma syntax-highlighting tokens,
+	// keyed by Chroma/Pygments short token name (e.g. "kc" for
+	// KeywordConstant, "s" for LiteralString). Values follow Chroma's own
+	// style-entry grammar: "#hex [bold] [italic] [underline] [bg:#hex]".
+	ChromaOverrides map[string]string `json:"chromaOverrides,omitempty"`
 }
 
-// ColorsJSON represents color definitions in JSON format.
-// Colors can be specified as:
-// - Hex: "#7D56F4"
-// - ANSI 256: "212"
-// - Named: "red", "blue", etc.
+// ColorsJSON represents color definitions in JSON format. Each field
+// accepts either:
+// - A plain string: hex ("#7D56F4"), ANSI 256 ("212"), or named ("red")
+// - An adaptive object: {"light": "#...", "dark": "#..."}
+// - A complete-color object: {"trueColor": "#...", "ansi256": "212", "ansi": "5"}
+// - A complete-adaptive object combining the two: {"light": {...}, "dark": {...}}
 type ColorsJSON struct {
-	Primary    string `json:"primary"`
-	Secondary  string `json:"secondary"`
-	Background string `json:"background"`
-	Surface    string `json:"surface"`
-	Overlay    string `json:"overlay"`
+	Primary    json.RawMessage `json:"primary"`
+	Secondary  json.RawMessage `json:"secondary"`
+	Background json.RawMessage `json:"background"`
+	Surface    json.RawMessage `json:"surface"`
+	Overlay    json.RawMessage `json:"overlay"`
 
-	Text      string `json:"text"`
-	TextMuted string `json:"textMuted"`
-	TextDim   string `json:"textDim"`
+	Text      json.RawMessage `json:"text"`
+	TextMuted json.RawMessage `json:"textMuted"`
+	TextDim   json.RawMessage `json:"textDim"`
 
-	Success string `json:"success"`
-	Warning string `json:"warning"`
-	Error   string `json:"error"`
-	Info    string `json:"info"`
+	Success json.RawMessage `json:"success"`
+	Warning json.RawMessage `json:"warning"`
+	Error   json.RawMessage `json:"error"`
+	Info    json.RawMessage `json:"info"`
 
-	Accent1 string `json:"accent1"`
-	Accent2 string `json:"accent2"`
-	Accent3 string `json:"accent3"`
+	Accent1 json.RawMessage `json:"accent1"`
+	Accent2 json.RawMessage `json:"accent2"`
+	Accent3 json.RawMessage `json:"accent3"`
 }
 
 // LoadThemeFromFile loads a theme from a JSON file.
@@ -65,37 +96,227 @@ func LoadThemeFromJSON(data []byte) (*Theme, error) {
 	return tj.ToTheme()
 }
 
+// LoadFromJSON loads a theme from an io.Reader, for sources LoadThemeFromFile's
+// path-based API doesn't fit - an embed.FS entry, an HTTP response body, etc.
+func LoadFromJSON(r io.Reader) (*Theme, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+	return LoadThemeFromJSON(data)
+}
+
 // ToTheme converts a ThemeJSON to a Theme.
 func (tj *ThemeJSON) ToTheme() (*Theme, error) {
-	colors := Colors{
-		Primary:    parseColor(tj.Colors.Primary),
-		Secondary:  parseColor(tj.Colors.Secondary),
-		Background: parseColor(tj.Colors.Background),
-		Surface:    parseColor(tj.Colors.Surface),
-		Overlay:    parseColor(tj.Colors.Overlay),
-		Text:       parseColor(tj.Colors.Text),
-		TextMuted:  parseColor(tj.Colors.TextMuted),
-		TextDim:    parseColor(tj.Colors.TextDim),
-		Success:    parseColor(tj.Colors.Success),
-		Warning:    parseColor(tj.Colors.Warning),
-		Error:      parseColor(tj.Colors.Error),
-		Info:       parseColor(tj.Colors.Info),
-		Accent1:    parseColor(tj.Colors.Accent1),
-		Accent2:    parseColor(tj.Colors.Accent2),
-		Accent3:    parseColor(tj.Colors.Accent3),
+	colors := Colors{}
+	fields := []struct {
+		name string
+		raw  json.RawMessage
+		dst  *lipgloss.TerminalColor
+	}{
+		{"primary", tj.Colors.Primary, &colors.Primary},
+		{"secondary", tj.Colors.Secondary, &colors.Secondary},
+		{"background", tj.Colors.Background, &colors.Background},
+		{"surface", tj.Colors.Surface, &colors.Surface},
+		{"overlay", tj.Colors.Overlay, &colors.Overlay},
+		{"text", tj.Colors.Text, &colors.Text},
+		{"textMuted", tj.Colors.TextMuted, &colors.TextMuted},
+		{"textDim", tj.Colors.TextDim, &colors.TextDim},
+		{"success", tj.Colors.Success, &colors.Success},
+		{"warning", tj.Colors.Warning, &colors.Warning},
+		{"error", tj.Colors.Error, &colors.Error},
+		{"info", tj.Colors.Info, &colors.Info},
+		{"accent1", tj.Colors.Accent1, &colors.Accent1},
+		{"accent2", tj.Colors.Accent2, &colors.Accent2},
+		{"accent3", tj.Colors.Accent3, &colors.Accent3},
+	}
+	for _, f := range fields {
+		c, err := tj.resolveColor(f.raw)
+		if err != nil {
+			return nil, fmt.Errorf("colors.%s: %w", f.name, err)
+		}
+		*f.dst = c
+	}
+
+	overrides := make(map[string]string, len(tj.ChromaOverrides)+len(tj.Syntax))
+	for token, value := range tj.ChromaOverrides {
+		if !chromaTokenNames[token] {
+			return nil, fmt.Errorf("unknown chromaOverrides token %q", token)
+		}
+		value = tj.resolvePaletteRef(value)
+		if !chromaStyleEntryPattern.MatchString(value) {
+			return nil, fmt.Errorf("chromaOverrides[%q]: invalid style entry %q, want \"#hex [bold] [italic] [underline] [bg:#hex]\"", token, value)
+		}
+		overrides[token] = value
+	}
+	for class, value := range tj.Syntax {
+		token, ok := chromaClassNames[class]
+		if !ok {
+			return nil, fmt.Errorf("unknown syntax token class %q", class)
+		}
+		value = tj.resolvePaletteRef(value)
+		if !chromaStyleEntryPattern.MatchString(value) {
+			return nil, fmt.Errorf("syntax[%q]: invalid style entry %q, want \"#hex [bold] [italic] [underline] [bg:#hex]\"", class, value)
+		}
+		overrides[token] = value
+	}
+	if len(overrides) == 0 {
+		overrides = nil
 	}
 
 	return &Theme{
-		ID:          tj.ID,
-		Name:        tj.Name,
-		Description: tj.Description,
-		Author:      tj.Author,
-		Version:     tj.Version,
-		Colors:      colors,
-		Styles:      BuildStyles(colors),
+		ID:              tj.ID,
+		Name:            tj.Name,
+		Description:     tj.Description,
+		Author:          tj.Author,
+		Version:         tj.Version,
+		Colors:          colors,
+		Styles:          BuildStyles(colors),
+		ChromaOverrides: overrides,
 	}, nil
 }
 
+// resolveColor parses a ColorsJSON field, substituting a Palette entry
+// when the raw value is a bare string matching a palette name.
+func (tj *ThemeJSON) resolveColor(raw json.RawMessage) (lipgloss.TerminalColor, error) {
+	var name string
+	if len(tj.Palette) > 0 {
+		if err := json.Unmarshal(raw, &name); err == nil {
+			if hex, ok := tj.Palette[name]; ok {
+				return parseColor(hex), nil
+			}
+		}
+	}
+	return parseColorValue(raw)
+}
+
+// resolvePaletteRef substitutes a Syntax/ChromaOverrides value's leading
+// color token with its Palette entry when it names one, leaving any
+// trailing attributes (bold, italic, bg:#hex, ...) untouched.
+func (tj *ThemeJSON) resolvePaletteRef(value string) string {
+	if len(tj.Palette) == 0 {
+		return value
+	}
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return value
+	}
+	if hex, ok := tj.Palette[fields[0]]; ok {
+		fields[0] = hex
+	}
+	return strings.Join(fields, " ")
+}
+
+// chromaClassNames maps Chroma token class names - the Go identifiers
+// from the chroma package, as used in a theme's JSON "syntax" block
+// (e.g. "KeywordConstant", "LiteralString") - to the Chroma/Pygments
+// short token names ChromaOverrides and views.BuildChromaStyle use.
+var chromaClassNames = map[string]string{
+	"Background":       "bg",
+	"LineNumbers":      "ln",
+	"LineNumbersTable": "lnt",
+
+	"Keyword":            "k",
+	"KeywordConstant":    "kc",
+	"KeywordDeclaration": "kd",
+	"KeywordNamespace":   "kn",
+	"KeywordType":        "kt",
+
+	"Name":          "n",
+	"NameAttribute": "na",
+	"NameBuiltin":   "nb",
+	"NameClass":     "nc",
+	"NameConstant":  "no",
+	"NameDecorator": "nd",
+	"NameEntity":    "ni",
+	"NameException": "ne",
+	"NameFunction":  "nf",
+	"NameLabel":     "nl",
+	"NameNamespace": "nn",
+	"NameTag":       "nt",
+	"NameVariable":  "nv",
+
+	"LiteralString":         "s",
+	"LiteralStringAffix":    "sa",
+	"LiteralStringBacktick": "sb",
+	"LiteralStringChar":     "sc",
+	"LiteralStringDoc":      "sd",
+	"LiteralStringEscape":   "se",
+	"LiteralStringHeredoc":  "sh",
+	"LiteralStringInterpol": "si",
+	"LiteralStringRegex":    "sr",
+	"LiteralStringSingle":   "s1",
+	"LiteralStringDouble":   "s2",
+
+	"LiteralNumber":            "m",
+	"LiteralNumberBin":         "mb",
+	"LiteralNumberFloat":       "mf",
+	"LiteralNumberHex":         "mh",
+	"LiteralNumberInteger":     "mi",
+	"LiteralNumberIntegerLong": "il",
+	"LiteralNumberOct":         "mo",
+
+	"Operator":     "o",
+	"OperatorWord": "ow",
+	"Punctuation":  "p",
+
+	"Comment":          "c",
+	"CommentHashbang":  "ch",
+	"CommentMultiline": "cm",
+	"CommentPreproc":   "cp",
+	"CommentSingle":    "c1",
+	"CommentSpecial":   "cs",
+
+	"Generic":           "g",
+	"GenericDeleted":    "gd",
+	"GenericEmph":       "ge",
+	"GenericError":      "gr",
+	"GenericHeading":    "gh",
+	"GenericInserted":   "gi",
+	"GenericOutput":     "go",
+	"GenericPrompt":     "gp",
+	"GenericStrong":     "gs",
+	"GenericSubheading": "gu",
+	"GenericTraceback":  "gt",
+
+	"Error": "err",
+}
+
+// chromaTokenNames lists the Chroma/Pygments short token names that
+// chromaOverrides may target. Covers the token set views.BuildChromaStyle
+// derives from theme colors except LiteralStringDelimiter, which has no
+// short name here and so can't be overridden.
+var chromaTokenNames = map[string]bool{
+	"bg": true, "ln": true, "lnt": true,
+
+	"k": true, "kc": true, "kd": true, "kn": true, "kt": true,
+
+	"n": true, "na": true, "nb": true, "nc": true, "nd": true,
+	"ne": true, "nf": true, "ni": true, "nl": true, "nn": true,
+	"nt": true, "nv": true, "no": true,
+
+	"s": true, "sa": true, "sb": true, "sc": true, "sd": true,
+	"se": true, "sh": true, "si": true, "sr": true, "s1": true,
+	"s2": true,
+
+	"m": true, "mb": true, "mf": true, "mh": true, "mi": true,
+	"il": true, "mo": true,
+
+	"o": true, "ow": true, "p": true,
+
+	"c": true, "ch": true, "cm": true, "cp": true, "c1": true, "cs": true,
+
+	"g": true, "gd": true, "ge": true, "gr": true, "gh": true,
+	"gi": true, "go": true, "gp": true, "gs": true, "gu": true, "gt": true,
+
+	"err": true,
+}
+
+// chromaStyleEntryPattern validates Chroma's style-entry grammar: a hex
+// color (or "none") optionally followed by bold/italic/underline/noinherit
+// and a "bg:#hex" background.
+var chromaStyleEntryPattern = regexp.MustCompile(`^(none|#([0-9a-fA-F]{3}|[0-9a-fA-F]{6}))?(\s+(bold|italic|underline|noinherit|bg:#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})))*\s*$`)
+
 // parseColor converts a color string to a lipgloss.Color.
 // Accepts hex (#7D56F4), ANSI numbers (212), or color names.
 func parseColor(s string) lipgloss.TerminalColor {
@@ -105,6 +326,88 @@ func parseColor(s string) lipgloss.TerminalColor {
 	return lipgloss.Color(s)
 }
 
+// completeColorJSON is the object form of a single light/dark branch of a
+// color, mirroring lipgloss.CompleteColor.
+type completeColorJSON struct {
+	TrueColor string `json:"trueColor,omitempty"`
+	ANSI256   string `json:"ansi256,omitempty"`
+	ANSI      string `json:"ansi,omitempty"`
+}
+
+// parseColorValue parses one ColorsJSON field, which may be a plain string,
+// an adaptive object ({"light":..., "dark":...}), a complete-color object
+// ({"trueColor":..., "ansi256":..., "ansi":...}), or a complete-adaptive
+// object combining the two. Returns the narrowest lipgloss.TerminalColor
+// type that represents what was given, so round-tripping through
+// ExportThemeToJSON doesn't lose information.
+func parseColorValue(raw json.RawMessage) (lipgloss.TerminalColor, error) {
+	if len(raw) == 0 {
+		return lipgloss.Color(""), nil
+	}
+
+	var plain string
+	if err := json.Unmarshal(raw, &plain); err == nil {
+		return parseColor(plain), nil
+	}
+
+	var obj struct {
+		Light json.RawMessage `json:"light"`
+		Dark  json.RawMessage `json:"dark"`
+		completeColorJSON
+	}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("invalid color value %s: %w", raw, err)
+	}
+
+	if len(obj.Light) > 0 || len(obj.Dark) > 0 {
+		light, lightComplete, err := parseColorSide(obj.Light)
+		if err != nil {
+			return nil, err
+		}
+		dark, darkComplete, err := parseColorSide(obj.Dark)
+		if err != nil {
+			return nil, err
+		}
+		if lightComplete != nil || darkComplete != nil {
+			cc := func(c *completeColorJSON, s string) lipgloss.CompleteColor {
+				if c == nil {
+					// A plain-string side of a mixed object keeps its color
+					// at every profile.
+					return lipgloss.CompleteColor{TrueColor: s, ANSI256: s, ANSI: s}
+				}
+				return lipgloss.CompleteColor{TrueColor: c.TrueColor, ANSI256: c.ANSI256, ANSI: c.ANSI}
+			}
+			return lipgloss.CompleteAdaptiveColor{Light: cc(lightComplete, light), Dark: cc(darkComplete, dark)}, nil
+		}
+		return lipgloss.AdaptiveColor{Light: light, Dark: dark}, nil
+	}
+
+	if obj.TrueColor != "" || obj.ANSI256 != "" || obj.ANSI != "" {
+		return lipgloss.CompleteColor{TrueColor: obj.TrueColor, ANSI256: obj.ANSI256, ANSI: obj.ANSI}, nil
+	}
+
+	return nil, fmt.Errorf("color object %s must set light/dark or trueColor/ansi256/ansi", raw)
+}
+
+// parseColorSide parses one branch ("light" or "dark") of an adaptive
+// color object. It may itself be a plain string or a complete-color
+// object; the latter is also returned so the caller can tell whether to
+// build an AdaptiveColor or a CompleteAdaptiveColor.
+func parseColorSide(raw json.RawMessage) (string, *completeColorJSON, error) {
+	if len(raw) == 0 {
+		return "", nil, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil, nil
+	}
+	var cc completeColorJSON
+	if err := json.Unmarshal(raw, &cc); err != nil {
+		return "", nil, fmt.Errorf("invalid color value %s: %w", raw, err)
+	}
+	return cc.TrueColor, &cc, nil
+}
+
 // LoadThemesFromDirectory loads all JSON themes from a directory.
 // Returns the number of themes loaded and any errors encountered.
 func LoadThemesFromDirectory(dir string) (int, []error) {
@@ -173,42 +476,69 @@ func LoadThemeFromEnv() error {
 // ExportThemeToJSON exports a theme to JSON format.
 func ExportThemeToJSON(t *Theme) ([]byte, error) {
 	tj := ThemeJSON{
-		ID:          t.ID,
-		Name:        t.Name,
-		Description: t.Description,
-		Author:      t.Author,
-		Version:     t.Version,
-		Colors: ColorsJSON{
-			Primary:    colorToString(t.Colors.Primary),
-			Secondary:  colorToString(t.Colors.Secondary),
-			Background: colorToString(t.Colors.Background),
-			Surface:    colorToString(t.Colors.Surface),
-			Overlay:    colorToString(t.Colors.Overlay),
-			Text:       colorToString(t.Colors.Text),
-			TextMuted:  colorToString(t.Colors.TextMuted),
-			TextDim:    colorToString(t.Colors.TextDim),
-			Success:    colorToString(t.Colors.Success),
-			Warning:    colorToString(t.Colors.Warning),
-			Error:      colorToString(t.Colors.Error),
-			Info:       colorToString(t.Colors.Info),
-			Accent1:    colorToString(t.Colors.Accent1),
-			Accent2:    colorToString(t.Colors.Accent2),
-			Accent3:    colorToString(t.Colors.Accent3),
-		},
+		ID:              t.ID,
+		Name:            t.Name,
+		Description:     t.Description,
+		Author:          t.Author,
+		Version:         t.Version,
+		ChromaOverrides: t.ChromaOverrides,
+	}
+	colorFields := []struct {
+		dst *json.RawMessage
+		c   lipgloss.TerminalColor
+	}{
+		{&tj.Colors.Primary, t.Colors.Primary},
+		{&tj.Colors.Secondary, t.Colors.Secondary},
+		{&tj.Colors.Background, t.Colors.Background},
+		{&tj.Colors.Surface, t.Colors.Surface},
+		{&tj.Colors.Overlay, t.Colors.Overlay},
+		{&tj.Colors.Text, t.Colors.Text},
+		{&tj.Colors.TextMuted, t.Colors.TextMuted},
+		{&tj.Colors.TextDim, t.Colors.TextDim},
+		{&tj.Colors.Success, t.Colors.Success},
+		{&tj.Colors.Warning, t.Colors.Warning},
+		{&tj.Colors.Error, t.Colors.Error},
+		{&tj.Colors.Info, t.Colors.Info},
+		{&tj.Colors.Accent1, t.Colors.Accent1},
+		{&tj.Colors.Accent2, t.Colors.Accent2},
+		{&tj.Colors.Accent3, t.Colors.Accent3},
+	}
+	for _, f := range colorFields {
+		raw, err := colorToJSON(f.c)
+		if err != nil {
+			return nil, err
+		}
+		*f.dst = raw
 	}
 
 	return json.MarshalIndent(tj, "", "  ")
 }
 
-// colorToString converts a TerminalColor back to its string representation.
-func colorToString(c lipgloss.TerminalColor) string {
-	if c == nil {
-		return ""
-	}
-	// For simple Color types, we can use the string value
-	if color, ok := c.(lipgloss.Color); ok {
-		return string(color)
+// colorToJSON converts a TerminalColor back to its ColorsJSON
+// representation, preserving AdaptiveColor/CompleteColor/
+// CompleteAdaptiveColor instead of collapsing them to a plain string.
+func colorToJSON(c lipgloss.TerminalColor) (json.RawMessage, error) {
+	switch v := c.(type) {
+	case nil:
+		return json.Marshal("")
+	case lipgloss.Color:
+		return json.Marshal(string(v))
+	case lipgloss.AdaptiveColor:
+		return json.Marshal(struct {
+			Light string `json:"light"`
+			Dark  string `json:"dark"`
+		}{v.Light, v.Dark})
+	case lipgloss.CompleteColor:
+		return json.Marshal(completeColorJSON{TrueColor: v.TrueColor, ANSI256: v.ANSI256, ANSI: v.ANSI})
+	case lipgloss.CompleteAdaptiveColor:
+		return json.Marshal(struct {
+			Light completeColorJSON `json:"light"`
+			Dark  completeColorJSON `json:"dark"`
+		}{
+			Light: completeColorJSON{TrueColor: v.Light.TrueColor, ANSI256: v.Light.ANSI256, ANSI: v.Light.ANSI},
+			Dark:  completeColorJSON{TrueColor: v.Dark.TrueColor, ANSI256: v.Dark.ANSI256, ANSI: v.Dark.ANSI},
+		})
+	default:
+		return json.Marshal("")
 	}
-	// For adaptive colors, return empty (they need special handling)
-	return ""
 }
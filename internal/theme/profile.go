@@ -0,0 +1,240 @@
+package theme
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/flight505/agentui/internal/theme/colorx"
+)
+
+// Profile enumerates terminal color capabilities, mirroring the levels
+// lipgloss/termenv already detect per-renderer (Ascii, ANSI, ANSI256,
+// TrueColor) but scoped to theme so BuildStylesFor can reason about "how far
+// down do I need to convert this palette" without importing termenv at every
+// call site.
+type Profile int
+
+const (
+	Ascii Profile = iota
+	ANSI16
+	ANSI256
+	TrueColor
+)
+
+// forcedProfile, when non-nil, overrides ProfileFromRenderer's detection.
+// Set by ForceProfile.
+var forcedProfile *Profile
+
+// ForceProfile pins ProfileFromRenderer to always return p, regardless of
+// what the renderer actually detects. Pass nil to restore normal detection.
+// Intended for tests and CI snapshots (e.g. rendering Theme.Preview for
+// every profile) that need deterministic output independent of the terminal
+// actually running them.
+func ForceProfile(p *Profile) {
+	forcedProfile = p
+}
+
+// ProfileFromRenderer returns r's detected color profile as a Profile,
+// honoring a pinned ForceProfile override if one is set.
+func ProfileFromRenderer(r *lipgloss.Renderer) Profile {
+	if forcedProfile != nil {
+		return *forcedProfile
+	}
+	switch r.ColorProfile() {
+	case termenv.TrueColor:
+		return TrueColor
+	case termenv.ANSI256:
+		return ANSI256
+	case termenv.ANSI:
+		return ANSI16
+	default:
+		return Ascii
+	}
+}
+
+// String returns the profile's name, used by Theme.Preview's swatch header.
+func (p Profile) String() string {
+	switch p {
+	case TrueColor:
+		return "TrueColor"
+	case ANSI256:
+		return "ANSI256"
+	case ANSI16:
+		return "ANSI16"
+	default:
+		return "Ascii"
+	}
+}
+
+// downsampleColors converts every entry of c to the nearest color
+// representable at profile p, resolving AdaptiveColor/CompleteAdaptiveColor
+// against darkBg first. TrueColor is a no-op passthrough, since every hex
+// color in a Theme is already true-color.
+func downsampleColors(c Colors, p Profile, darkBg bool) Colors {
+	if p == TrueColor {
+		return c
+	}
+	conv := func(tc lipgloss.TerminalColor) lipgloss.TerminalColor {
+		return downsampleColor(tc, p, darkBg)
+	}
+	return Colors{
+		Primary:    conv(c.Primary),
+		Secondary:  conv(c.Secondary),
+		Background: conv(c.Background),
+		Surface:    conv(c.Surface),
+		Overlay:    conv(c.Overlay),
+
+		Text:      conv(c.Text),
+		TextMuted: conv(c.TextMuted),
+		TextDim:   conv(c.TextDim),
+
+		Success: conv(c.Success),
+		Warning: conv(c.Warning),
+		Error:   conv(c.Error),
+		Info:    conv(c.Info),
+
+		Accent1: conv(c.Accent1),
+		Accent2: conv(c.Accent2),
+		Accent3: conv(c.Accent3),
+	}
+}
+
+// downsampleColor resolves tc against darkBg (for Adaptive/CompleteAdaptive
+// colors) and then converts it to the nearest color representable at
+// profile p.
+func downsampleColor(tc lipgloss.TerminalColor, p Profile, darkBg bool) lipgloss.TerminalColor {
+	resolved := resolveAdaptive(tc, darkBg)
+	if p == TrueColor {
+		return resolved
+	}
+
+	hex := hexOf(resolved)
+	if hex == "" {
+		// Not a color we know how to down-convert (e.g. lipgloss.NoColor);
+		// pass it through unchanged.
+		return resolved
+	}
+
+	switch p {
+	case ANSI256:
+		return lipgloss.Color(hex)
+	case ANSI16:
+		return lipgloss.Color(colorx.NearestANSI16Hex(hex))
+	default: // Ascii
+		return lipgloss.NoColor{}
+	}
+}
+
+// resolveAdaptive picks the branch of an AdaptiveColor/CompleteAdaptiveColor
+// matching darkBg, leaving every other TerminalColor unchanged.
+func resolveAdaptive(tc lipgloss.TerminalColor, darkBg bool) lipgloss.TerminalColor {
+	switch v := tc.(type) {
+	case lipgloss.AdaptiveColor:
+		if darkBg {
+			return lipgloss.Color(v.Dark)
+		}
+		return lipgloss.Color(v.Light)
+	case lipgloss.CompleteAdaptiveColor:
+		if darkBg {
+			return lipgloss.CompleteColor(v.Dark)
+		}
+		return lipgloss.CompleteColor(v.Light)
+	default:
+		return tc
+	}
+}
+
+// hexOf extracts a "#RRGGBB" hex string from a resolved (non-adaptive)
+// TerminalColor, preferring TrueColor on a CompleteColor and converting a
+// bare ANSI index to hex via ANSI256ToHex. Returns "" for colors it doesn't
+// recognize (e.g. lipgloss.NoColor).
+func hexOf(tc lipgloss.TerminalColor) string {
+	switch v := tc.(type) {
+	case lipgloss.Color:
+		return hexOfCode(string(v))
+	case lipgloss.CompleteColor:
+		if v.TrueColor != "" {
+			return v.TrueColor
+		}
+		if v.ANSI256 != "" {
+			return hexOfCode(v.ANSI256)
+		}
+		return hexOfCode(v.ANSI)
+	default:
+		return ""
+	}
+}
+
+// hexOfCode normalizes a lipgloss color string ("#7D56F4" or a bare ANSI256
+// index like "212") to "#RRGGBB".
+func hexOfCode(code string) string {
+	if len(code) > 0 && code[0] == '#' {
+		return code
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return ""
+	}
+	return ANSI256ToHex(n)
+}
+
+// NearestANSI16Hex returns the entry of the 16-color ANSI palette closest to
+// hex in RGB space, for collapsing truecolor/256 palettes down to terminals
+// that only support the low 16 colors. Delegates to colorx, which owns the
+// table so theme and colorx don't each keep their own copy.
+func NearestANSI16Hex(hex string) string {
+	return colorx.NearestANSI16Hex(hex)
+}
+
+// ANSI256ToHex converts an xterm 256-color palette index (0-255) to its hex
+// color. Delegates to colorx, which owns the canonical table; exported here
+// too so other packages (e.g. views' Chroma style builder) that already
+// depend on theme don't also need to import colorx directly.
+func ANSI256ToHex(code int) string {
+	return colorx.ANSI256ToHex(code)
+}
+
+// Preview renders a swatch grid of t's named colors as they'd appear at
+// profile p, one "label ███" line per entry, so users can check how
+// CharmDark/CharmLight degrade on a 16-color or monochrome terminal before
+// picking a theme for a low-capability session.
+func (t *Theme) Preview(p Profile) string {
+	// Preview always resolves Adaptive/CompleteAdaptive colors against a
+	// dark background; Theme doesn't carry its own light/dark signal, and
+	// dark matches Current's default (CharmDark).
+	colors := downsampleColors(t.Colors, p, true)
+
+	swatches := []struct {
+		label string
+		color lipgloss.TerminalColor
+	}{
+		{"Primary", colors.Primary},
+		{"Secondary", colors.Secondary},
+		{"Background", colors.Background},
+		{"Surface", colors.Surface},
+		{"Overlay", colors.Overlay},
+		{"Text", colors.Text},
+		{"TextMuted", colors.TextMuted},
+		{"TextDim", colors.TextDim},
+		{"Success", colors.Success},
+		{"Warning", colors.Warning},
+		{"Error", colors.Error},
+		{"Info", colors.Info},
+		{"Accent1", colors.Accent1},
+		{"Accent2", colors.Accent2},
+		{"Accent3", colors.Accent3},
+	}
+
+	r := lipgloss.DefaultRenderer()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s)\n", t.Name, p)
+	for _, sw := range swatches {
+		block := r.NewStyle().Foreground(sw.color).Render("███")
+		fmt.Fprintf(&b, "%-10s %s\n", sw.label, block)
+	}
+	return b.String()
+}
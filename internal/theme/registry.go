@@ -0,0 +1,73 @@
+package theme
+
+import (
+	"reflect"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Registry binds theme selection to a single *lipgloss.Renderer, so a
+// multi-session program (e.g. a Wish/SSH server) can let each connected
+// client pick its own active theme without the selections clobbering each
+// other through the process-global Current / SetTheme.
+type Registry struct {
+	renderer *lipgloss.Renderer
+	session  *Session
+}
+
+// NewRegistry creates a Registry bound to r, seeded with whichever theme
+// theme.Current holds at the time. Typically created once per Wish/SSH
+// connection from that connection's own renderer.
+func NewRegistry(r *lipgloss.Renderer) *Registry {
+	return &Registry{
+		renderer: r,
+		session:  Activate(r, Current),
+	}
+}
+
+// SetTheme switches the registry's active theme by ID, degrading its
+// palette and rebuilding its styles for the registry's renderer. Returns
+// false if name isn't a registered theme.
+func (reg *Registry) SetTheme(name string) bool {
+	t, ok := Available[name]
+	if !ok {
+		return false
+	}
+	reg.session = Activate(reg.renderer, *t)
+	return true
+}
+
+// Theme returns the registry's active theme.
+func (reg *Registry) Theme() Theme {
+	return reg.session.Theme
+}
+
+// Style looks up a named style on the registry's active theme, by the
+// same names as the fields on Styles (e.g. "Header", "InputFieldFocus").
+// Returns the zero Style and false if name isn't a known style.
+func (reg *Registry) Style(name string) (lipgloss.Style, bool) {
+	f := reflect.ValueOf(reg.session.Theme.Styles).FieldByName(name)
+	if !f.IsValid() {
+		return lipgloss.Style{}, false
+	}
+	style, ok := f.Interface().(lipgloss.Style)
+	return style, ok
+}
+
+// RegistryMsg carries a per-session Registry into a Bubbletea model,
+// conventionally delivered via InitRegistry from Model.Init so the model
+// can stash it instead of reading the process-global Current.
+type RegistryMsg struct {
+	Registry *Registry
+}
+
+// InitRegistry returns a tea.Cmd that immediately delivers reg as a
+// RegistryMsg. Wire it into a per-connection model's Init when hosting
+// AgentUI behind Wish/SSH, where each connection gets its own renderer
+// and therefore its own Registry.
+func InitRegistry(reg *Registry) tea.Cmd {
+	return func() tea.Msg {
+		return RegistryMsg{Registry: reg}
+	}
+}
@@ -3,6 +3,9 @@ package theme
 
 import (
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/flight505/agentui/internal/theme/colorx"
 )
 
 // Theme defines the visual appearance of the TUI.
@@ -17,6 +20,43 @@ type Theme struct {
 	// Visual
 	Colors Colors
 	Styles Styles
+
+	// ChromaOverrides tweaks individual Chroma syntax-highlighting tokens
+	// on top of the palette derived by views.BuildChromaStyle. Keys are
+	// Chroma/Pygments short token names (e.g. "kc", "s"); values follow
+	// Chroma's own style-entry grammar ("#hex [bold] [italic] [underline]
+	// [bg:#hex]"). Populated from ThemeJSON's chromaOverrides block.
+	ChromaOverrides map[string]string
+
+	// Fallbacks holds degraded color palettes for terminals that can't
+	// render Colors in full, keyed by the termenv color profile they
+	// target (termenv.Ascii, termenv.ANSI, termenv.ANSI256). Activate and
+	// SetTheme pick the best match for the renderer's detected profile
+	// before building styles, similar to how fx swaps to a monochrome
+	// theme on termenv.Ascii terminals.
+	Fallbacks map[termenv.Profile]*Colors
+}
+
+// paletteFor returns the best Colors for profile p: an exact Fallbacks
+// match, otherwise the theme's full Colors on a TrueColor terminal,
+// otherwise the richest available fallback that p can still render,
+// otherwise the theme's full Colors.
+func (t *Theme) paletteFor(p termenv.Profile) Colors {
+	if len(t.Fallbacks) == 0 {
+		return t.Colors
+	}
+	if c, ok := t.Fallbacks[p]; ok && c != nil {
+		return *c
+	}
+	if p == termenv.TrueColor {
+		return t.Colors
+	}
+	for candidate := p + 1; candidate <= termenv.Ascii; candidate++ {
+		if c, ok := t.Fallbacks[candidate]; ok && c != nil {
+			return *c
+		}
+	}
+	return t.Colors
 }
 
 // Colors defines the color palette using TerminalColor interface.
@@ -70,6 +110,7 @@ type Styles struct {
 	FormLabel       lipgloss.Style
 	FormInput       lipgloss.Style
 	FormButton      lipgloss.Style
+	FormButtonHover lipgloss.Style
 	FormButtonFocus lipgloss.Style
 
 	// Tables
@@ -99,6 +140,7 @@ type Styles struct {
 	Border    lipgloss.Style
 	Highlight lipgloss.Style
 	Muted     lipgloss.Style
+	Disabled  lipgloss.Style
 }
 
 // Current holds the active theme (set to CharmDark by default in charm.go init)
@@ -107,46 +149,127 @@ var Current Theme
 // Available lists all available themes.
 var Available = make(map[string]*Theme)
 
-// SetTheme changes the current theme.
+// SetTheme changes the current theme, degrading its palette to match the
+// default renderer's detected color profile (see Theme.Fallbacks).
 func SetTheme(name string) bool {
-	if theme, ok := Available[name]; ok {
-		Current = *theme
+	if t, ok := Available[name]; ok {
+		Current = activate(lipgloss.DefaultRenderer(), *t)
 		return true
 	}
 	return false
 }
 
+// Activate selects the palette of t that best matches r's detected color
+// profile (falling back to a degraded Fallbacks entry, or t.Colors if none
+// fit) and returns a Session with styles built against r. This is the
+// renderer-aware sibling of SetTheme, for per-connection Wish/SSH
+// sessions whose color profile may differ from the process default.
+func Activate(r *lipgloss.Renderer, t Theme) *Session {
+	return NewSession(r, activate(r, t))
+}
+
+// activate resolves t's palette for r's color profile and rebuilds t.Styles
+// from that palette, without building a Session, so SetTheme can reuse it
+// against the default renderer. Rebuilding here (rather than leaving it to
+// the caller) keeps t.Colors and t.Styles from diverging once Fallbacks
+// picks a degraded palette.
+func activate(r *lipgloss.Renderer, t Theme) Theme {
+	t.Colors = t.paletteFor(r.ColorProfile())
+	t.Styles = BuildStylesFor(r, t.Colors)
+	return t
+}
+
 // Register adds a theme to the available themes.
 func Register(t *Theme) {
 	Available[t.ID] = t
 }
 
-// BuildStyles creates all styles from a color palette.
-// Uses Charm aesthetic: rounded borders, clean spacing, high contrast.
+// Session binds an active Theme to a specific *lipgloss.Renderer so that
+// multi-session programs (e.g. a Wish/SSH server) can give each connection
+// its own PTY, color profile, and dark/light background detection instead
+// of sharing the process-global Current theme.
+type Session struct {
+	Renderer *lipgloss.Renderer
+	Theme    Theme
+}
+
+// NewSession creates a Session for the given renderer, building the
+// theme's styles against that renderer immediately.
+func NewSession(r *lipgloss.Renderer, t Theme) *Session {
+	t.Styles = BuildStylesFor(r, t.Colors)
+	return &Session{Renderer: r, Theme: t}
+}
+
+// SetTheme swaps the session's active theme, rebuilding styles against the
+// session's renderer.
+func (s *Session) SetTheme(t Theme) {
+	t.Styles = BuildStylesFor(s.Renderer, t.Colors)
+	s.Theme = t
+}
+
+// Styles returns the session's current styles.
+func (s *Session) Styles() Styles {
+	return s.Theme.Styles
+}
+
+// BuildStyles creates all styles from a color palette using the default
+// renderer. Kept as a compatibility shim for single-process TUI code that
+// doesn't manage its own *lipgloss.Renderer; it's equivalent to
+// BuildStylesFor(lipgloss.DefaultRenderer(), c).
 func BuildStyles(c Colors) Styles {
+	return BuildStylesFor(lipgloss.DefaultRenderer(), c)
+}
+
+// BuildStylesFor creates all styles from a color palette against the given
+// renderer. Uses Charm aesthetic: rounded borders, clean spacing, high
+// contrast. Building against a renderer (rather than the package-level
+// lipgloss.NewStyle()) is what lets each Wish/SSH session get its own
+// color profile and background detection.
+func BuildStylesFor(r *lipgloss.Renderer, c Colors) Styles {
+	// Down-convert every color to what r's detected profile can actually
+	// render before building styles, so a 16-color or monochrome SSH
+	// session doesn't get hex colors it has to approximate on its own.
+	darkBg := r.HasDarkBackground()
+	c = downsampleColors(c, ProfileFromRenderer(r), darkBg)
+
+	// Hover/pressed/disabled variants derived from the palette in Oklab
+	// space instead of hard-coding every shade in each theme, plus a
+	// foreground for text drawn on top of Primary that's picked for WCAG
+	// readability rather than assumed to be Background. downsampleColors
+	// leaves Adaptive/CompleteAdaptive colors unresolved at TrueColor (no
+	// down-conversion needed), so resolve them against r's own background
+	// here — colorx has no renderer of its own and would otherwise
+	// resolve them against lipgloss.DefaultRenderer(), the wrong session
+	// on a Wish/SSH server whose client background differs.
+	primary := resolveAdaptive(c.Primary, darkBg)
+	hoverPrimary := colorx.Lighten(primary, 0.15)
+	pressedPrimary := colorx.Darken(primary, 0.15)
+	disabledText := colorx.Darken(resolveAdaptive(c.TextMuted, darkBg), 0.2)
+	textOnPrimary := readableOn(primary, resolveAdaptive(c.Text, darkBg), resolveAdaptive(c.Background, darkBg))
+
 	// Charm consistently uses rounded borders
 	border := lipgloss.RoundedBorder()
 
 	return Styles{
 		// Header/Footer
-		Header: lipgloss.NewStyle().
+		Header: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Primary).
 			Padding(0, 2).
 			Bold(true),
 
-		Footer: lipgloss.NewStyle().
+		Footer: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.TextMuted).
 			Padding(0, 2),
 
-		StatusBar: lipgloss.NewStyle().
+		StatusBar: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.TextMuted).
 			Padding(0, 1),
 
 		// Messages - Charm style with rounded borders
-		UserMessage: lipgloss.NewStyle().
+		UserMessage: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Text).
 			Border(border).
@@ -155,58 +278,58 @@ func BuildStyles(c Colors) Styles {
 			MarginTop(1).
 			MarginBottom(1),
 
-		AssistantMessage: lipgloss.NewStyle().
+		AssistantMessage: r.NewStyle().
 			Foreground(c.Text).
 			Padding(1, 2).
 			MarginTop(1).
 			MarginBottom(1),
 
-		SystemMessage: lipgloss.NewStyle().
+		SystemMessage: r.NewStyle().
 			Foreground(c.TextMuted).
 			Italic(true).
 			Padding(0, 2),
 
 		// Input - subtle border that pops on focus
-		InputField: lipgloss.NewStyle().
+		InputField: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Text).
 			Border(border).
 			BorderForeground(c.TextDim).
 			Padding(0, 1),
 
-		InputFieldFocus: lipgloss.NewStyle().
+		InputFieldFocus: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Text).
 			Border(border).
 			BorderForeground(c.Primary).
 			Padding(0, 1),
 
-		InputPrompt: lipgloss.NewStyle().
+		InputPrompt: r.NewStyle().
 			Foreground(c.Primary).
 			Bold(true),
 
 		// Forms
-		FormContainer: lipgloss.NewStyle().
+		FormContainer: r.NewStyle().
 			Background(c.Surface).
 			Border(border).
 			BorderForeground(c.Primary).
 			Padding(1, 2).
 			Margin(1),
 
-		FormTitle: lipgloss.NewStyle().
+		FormTitle: r.NewStyle().
 			Foreground(c.Primary).
 			Bold(true).
 			MarginBottom(1),
 
-		FormLabel: lipgloss.NewStyle().
+		FormLabel: r.NewStyle().
 			Foreground(c.Text),
 
-		FormInput: lipgloss.NewStyle().
+		FormInput: r.NewStyle().
 			Background(c.Overlay).
 			Foreground(c.Text).
 			Padding(0, 1),
 
-		FormButton: lipgloss.NewStyle().
+		FormButton: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.TextMuted).
 			Border(border).
@@ -214,74 +337,82 @@ func BuildStyles(c Colors) Styles {
 			Padding(0, 2).
 			MarginRight(1),
 
-		FormButtonFocus: lipgloss.NewStyle().
-			Background(c.Primary).
-			Foreground(c.Background).
+		FormButtonHover: r.NewStyle().
+			Background(hoverPrimary).
+			Foreground(textOnPrimary).
+			Border(border).
+			BorderForeground(c.Primary).
+			Padding(0, 2).
+			MarginRight(1),
+
+		FormButtonFocus: r.NewStyle().
+			Background(pressedPrimary).
+			Foreground(textOnPrimary).
 			Border(border).
 			BorderForeground(c.Primary).
 			Padding(0, 2).
 			MarginRight(1),
 
 		// Tables
-		TableContainer: lipgloss.NewStyle().
+		TableContainer: r.NewStyle().
 			Border(border).
 			BorderForeground(c.TextDim).
 			Padding(0, 1),
 
-		TableHeader: lipgloss.NewStyle().
+		TableHeader: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Primary).
 			Bold(true).
 			Padding(0, 1),
 
-		TableRow: lipgloss.NewStyle().
+		TableRow: r.NewStyle().
 			Foreground(c.Text).
 			Padding(0, 1),
 
-		TableRowAlt: lipgloss.NewStyle().
+		TableRowAlt: r.NewStyle().
 			Background(c.Surface).
 			Foreground(c.Text).
 			Padding(0, 1),
 
-		TableSelected: lipgloss.NewStyle().
+		TableSelected: r.NewStyle().
 			Background(c.Primary).
-			Foreground(c.Background).
+			Foreground(textOnPrimary).
 			Padding(0, 1),
 
 		// Code
-		CodeContainer: lipgloss.NewStyle().
+		CodeContainer: r.NewStyle().
 			Background(c.Surface).
 			Border(border).
 			BorderForeground(c.TextDim).
 			Padding(1),
 
-		CodeTitle: lipgloss.NewStyle().
+		CodeTitle: r.NewStyle().
 			Foreground(c.TextMuted).
 			Italic(true),
 
 		// Alerts
-		AlertInfo: lipgloss.NewStyle().
+		AlertInfo: r.NewStyle().
 			Border(border).
 			BorderForeground(c.Info).
 			Foreground(c.Text).
 			Padding(1, 2).
 			Margin(1),
 
-		AlertSuccess: lipgloss.NewStyle().
+		AlertSuccess: r.NewStyle().
 			Border(border).
 			BorderForeground(c.Success).
 			Foreground(c.Text).
 			Padding(1, 2).
 			Margin(1),
 
-		AlertWarning: lipgloss.NewStyle().
+		AlertWarning: r.NewStyle().
 			Border(border).
 			BorderForeground(c.Warning).
 			Foreground(c.Text).
 			Padding(1, 2).
 			Margin(1),
 
-		AlertError: lipgloss.NewStyle().
+		AlertError: r.NewStyle().
 			Border(border).
 			BorderForeground(c.Error).
 			Foreground(c.Text).
@@ -289,28 +420,45 @@ func BuildStyles(c Colors) Styles {
 			Margin(1),
 
 		// Progress
-		ProgressContainer: lipgloss.NewStyle().
+		ProgressContainer: r.NewStyle().
 			Padding(1, 2),
 
-		ProgressBar: lipgloss.NewStyle().
+		ProgressBar: r.NewStyle().
 			Foreground(c.Primary),
 
-		ProgressComplete: lipgloss.NewStyle().
+		ProgressComplete: r.NewStyle().
 			Foreground(c.Success),
 
 		// Misc
-		Spinner: lipgloss.NewStyle().
+		Spinner: r.NewStyle().
 			Foreground(c.Primary),
 
-		Border: lipgloss.NewStyle().
+		Border: r.NewStyle().
 			Border(border).
 			BorderForeground(c.TextDim),
 
-		Highlight: lipgloss.NewStyle().
+		Highlight: r.NewStyle().
 			Foreground(c.Primary).
 			Bold(true),
 
-		Muted: lipgloss.NewStyle().
+		Muted: r.NewStyle().
 			Foreground(c.TextMuted),
+
+		Disabled: r.NewStyle().
+			Foreground(disabledText),
+	}
+}
+
+// readableOn picks whichever of fg1/fg2 has better WCAG contrast against
+// bg, preferring fg1 if it already clears the AA threshold for normal text
+// (4.5) so a theme's usual Text color is kept unless it genuinely doesn't
+// read well against bg.
+func readableOn(bg, fg1, fg2 lipgloss.TerminalColor) lipgloss.TerminalColor {
+	if colorx.Contrast(fg1, bg) >= 4.5 {
+		return fg1
+	}
+	if colorx.Contrast(fg2, bg) > colorx.Contrast(fg1, bg) {
+		return fg2
 	}
+	return fg1
 }
@@ -0,0 +1,68 @@
+package theme
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ThemeChangedMsg is sent (conventionally through a Bubbletea program's
+// message channel, e.g. via tea.Program.Send) whenever WatchDir detects
+// that a theme JSON file on disk was added or changed. ID is the
+// (re-)registered theme's ID, so a model can re-activate it if it's the
+// one currently in use.
+type ThemeChangedMsg struct {
+	ID string
+}
+
+// WatchDir watches dir for added or modified *.json theme files,
+// re-registering each one with Register and calling onChange with a
+// ThemeChangedMsg, similar to how fx lets FX_THEME pick a theme at
+// runtime without a restart. Call the returned stop func to close the
+// watcher; onChange may be nil if the caller only cares that Available
+// stays current.
+func WatchDir(dir string, onChange func(ThemeChangedMsg)) (stop func() error, err error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start theme watcher: %w", err)
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !strings.HasSuffix(event.Name, ".json") {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+					continue
+				}
+
+				t, err := LoadThemeFromFile(event.Name)
+				if err != nil {
+					// A theme mid-write or briefly invalid shouldn't kill
+					// the watcher; the next write event will retry it.
+					continue
+				}
+				Register(t)
+				if onChange != nil {
+					onChange(ThemeChangedMsg{ID: t.ID})
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return watcher.Close, nil
+}
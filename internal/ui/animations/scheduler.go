@@ -0,0 +1,81 @@
+package animations
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Animator is the subset of spring behavior the Scheduler needs to drive
+// shared frame ticks. *Spring, *PositionSpring, and *OpacitySpring all
+// satisfy it.
+type Animator interface {
+	Update() bool
+	IsActive() bool
+}
+
+// FrameMsg is sent once per frame by the Scheduler's shared ticker.
+type FrameMsg time.Time
+
+// Scheduler drives every attached animator from a single shared 60fps
+// ticker instead of each component running its own TickCmd, so N
+// animating list items cost one wakeup per frame instead of N. A
+// Bubbletea Program typically creates one Scheduler, stores it on the
+// top-level model, and wires FrameMsg through to Scheduler.Update.
+type Scheduler struct {
+	animators []Animator
+}
+
+// NewScheduler creates an empty Scheduler.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Attach registers a for the scheduler to advance on every FrameMsg. Safe
+// to call with an animator that's already attached; it won't be
+// duplicated.
+func (s *Scheduler) Attach(a Animator) {
+	for _, existing := range s.animators {
+		if existing == a {
+			return
+		}
+	}
+	s.animators = append(s.animators, a)
+}
+
+// Update advances every attached animator by one frame, drops any that
+// have settled, and returns a tea.Cmd that re-arms the shared ticker only
+// if at least one animator is still active — so an idle scene stops
+// ticking entirely instead of polling at 60fps forever.
+func (s *Scheduler) Update(FrameMsg) tea.Cmd {
+	frame := s.animators
+	kept := make([]Animator, 0, len(frame))
+	for _, a := range frame {
+		if a.Update() {
+			kept = append(kept, a)
+		}
+	}
+
+	// An animator settling above can fire an OnSettle callback that
+	// Attaches the next Step (e.g. Sequence chaining to its next stage);
+	// that append lands past frame's original length, so fold it into
+	// kept instead of letting the reassignment below silently drop it.
+	if tail := s.animators[len(frame):]; len(tail) > 0 {
+		kept = append(kept, tail...)
+	}
+	s.animators = kept
+
+	if len(s.animators) == 0 {
+		return nil
+	}
+	return Tick()
+}
+
+// Tick returns a command that sends FrameMsg after one frame (60fps). Wire
+// it into the Program's Init, and again from Update whenever Scheduler.Update
+// returns a non-nil command, to keep the shared ticker running.
+func Tick() tea.Cmd {
+	return tea.Tick(time.Second/60, func(t time.Time) tea.Msg {
+		return FrameMsg(t)
+	})
+}
@@ -0,0 +1,36 @@
+package animations
+
+import "testing"
+
+// TestScheduler_SequenceAdvancesPastFirstStep guards against a scheduler
+// bug where Attach calls made mid-frame (from a settling spring's
+// OnSettle callback chaining into the next Sequence step) were discarded
+// by Update's end-of-frame `s.animators = kept` reassignment, stalling
+// every Sequence after its first step.
+func TestScheduler_SequenceAdvancesPastFirstStep(t *testing.T) {
+	sched := NewScheduler()
+
+	first := NewSpring(FastSpringConfig())
+	second := NewSpring(FastSpringConfig())
+
+	Start(sched, Sequence(
+		SpringStep{Spring: first, Target: 1},
+		SpringStep{Spring: second, Target: 1},
+	))
+
+	secondStarted := false
+	for i := 0; i < 200; i++ {
+		cmd := sched.Update(FrameMsg{})
+		if second.IsActive() {
+			secondStarted = true
+			break
+		}
+		if cmd == nil {
+			break
+		}
+	}
+
+	if !secondStarted {
+		t.Fatal("second spring in the Sequence never started after the first settled")
+	}
+}
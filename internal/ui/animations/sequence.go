@@ -0,0 +1,82 @@
+package animations
+
+// Step is a composable animation unit: setting a single spring's target,
+// or a Sequence/Parallel combinator of other Steps. Start runs a Step
+// against a Scheduler.
+type Step interface {
+	start(sched *Scheduler, done func())
+}
+
+// SpringStep is a Step that attaches Spring to sched and sets its target,
+// the basic building block Sequence and Parallel chain together.
+type SpringStep struct {
+	Spring *Spring
+	Target float64
+}
+
+func (st SpringStep) start(sched *Scheduler, done func()) {
+	sched.Attach(st.Spring)
+	st.Spring.OnSettle(done)
+	st.Spring.SetTarget(st.Target)
+}
+
+// Start begins running step against sched, attaching whatever springs it
+// needs along the way. Use this to kick off a Sequence or Parallel built
+// from SpringStep leaves (e.g. fade-in then slide).
+func Start(sched *Scheduler, step Step) {
+	step.start(sched, nil)
+}
+
+// sequenceStep runs its steps one after another, starting each only once
+// the previous one's springs have all settled.
+type sequenceStep []Step
+
+// Sequence returns a Step that runs steps in order, each starting only
+// after the previous one settles — e.g. fade in, then slide into place.
+func Sequence(steps ...Step) Step {
+	return sequenceStep(steps)
+}
+
+func (s sequenceStep) start(sched *Scheduler, done func()) {
+	s.runFrom(sched, 0, done)
+}
+
+func (s sequenceStep) runFrom(sched *Scheduler, i int, done func()) {
+	if i >= len(s) {
+		if done != nil {
+			done()
+		}
+		return
+	}
+	s[i].start(sched, func() {
+		s.runFrom(sched, i+1, done)
+	})
+}
+
+// parallelStep runs its steps together, calling done once every one of
+// them has settled.
+type parallelStep []Step
+
+// Parallel returns a Step that starts steps together, settling once every
+// one of them has settled.
+func Parallel(steps ...Step) Step {
+	return parallelStep(steps)
+}
+
+func (p parallelStep) start(sched *Scheduler, done func()) {
+	remaining := len(p)
+	if remaining == 0 {
+		if done != nil {
+			done()
+		}
+		return
+	}
+	for _, step := range p {
+		step.start(sched, func() {
+			remaining--
+			if remaining == 0 && done != nil {
+				done()
+			}
+		})
+	}
+}
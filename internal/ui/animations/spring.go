@@ -15,6 +15,7 @@ type Spring struct {
 	current  float64
 	velocity float64
 	active   bool
+	onSettle func()
 }
 
 // SpringConfig defines spring physics parameters.
@@ -91,12 +92,25 @@ func (s *Spring) Update() bool {
 		s.current = s.target
 		s.velocity = 0
 		s.active = false
+		if s.onSettle != nil {
+			fn := s.onSettle
+			s.onSettle = nil
+			fn()
+		}
 		return false
 	}
 
 	return true
 }
 
+// OnSettle registers fn to run the next time the spring transitions from
+// active to settled, then clears itself so it doesn't re-fire on a later
+// SetTarget. Used by Sequence/Parallel to chain animation steps without
+// hand-wiring TickMsg handlers in every view.
+func (s *Spring) OnSettle(fn func()) {
+	s.onSettle = fn
+}
+
 // isSettled checks if a spring has settled to its target.
 func isSettled(current, velocity, target, threshold float64) bool {
 	// Check if position is close to target and velocity is low
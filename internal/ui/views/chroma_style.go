@@ -1,105 +1,210 @@
 package views
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/alecthomas/chroma/v2"
 	"github.com/charmbracelet/lipgloss"
+
 	"github.com/flight505/agentui/internal/theme"
 )
 
-// BuildChromaStyle creates a Chroma style from the current theme colors.
-// This ensures code syntax highlighting matches the Charm aesthetic.
+// BuildChromaStyle creates a Chroma style from the current theme colors
+// using the default renderer's detected background. This ensures code
+// syntax highlighting matches the Charm aesthetic.
 func BuildChromaStyle() *chroma.Style {
+	return BuildChromaStyleFor(lipgloss.DefaultRenderer())
+}
+
+// BuildChromaStyleFor creates a Chroma style from the current theme colors,
+// resolving AdaptiveColor/CompleteAdaptiveColor entries against r's
+// detected background so highlighting looks correct on both light and
+// dark terminals.
+func BuildChromaStyleFor(r *lipgloss.Renderer) *chroma.Style {
 	colors := theme.Current.Colors
 
 	// Convert lipgloss TerminalColor to hex strings for Chroma
 	// For simplicity, we'll use the theme's color scheme
 
-	return chroma.MustNewStyle("charm", chroma.StyleEntries{
+	entries := chroma.StyleEntries{
 		// Background
-		chroma.Background:       toChromaColor(colors.Surface),
-		chroma.LineNumbers:      toChromaColor(colors.TextDim),
-		chroma.LineNumbersTable: toChromaColor(colors.TextDim),
+		chroma.Background:       toChromaColor(r, colors.Surface),
+		chroma.LineNumbers:      toChromaColor(r, colors.TextDim),
+		chroma.LineNumbersTable: toChromaColor(r, colors.TextDim),
 
 		// Keywords
-		chroma.Keyword:            toChromaColor(colors.Primary) + " bold",
-		chroma.KeywordConstant:    toChromaColor(colors.Accent2),
-		chroma.KeywordDeclaration: toChromaColor(colors.Primary),
-		chroma.KeywordNamespace:   toChromaColor(colors.Accent2),
-		chroma.KeywordType:        toChromaColor(colors.Accent3),
+		chroma.Keyword:            toChromaColor(r, colors.Primary) + " bold",
+		chroma.KeywordConstant:    toChromaColor(r, colors.Accent2),
+		chroma.KeywordDeclaration: toChromaColor(r, colors.Primary),
+		chroma.KeywordNamespace:   toChromaColor(r, colors.Accent2),
+		chroma.KeywordType:        toChromaColor(r, colors.Accent3),
 
 		// Names
-		chroma.Name:             toChromaColor(colors.Text),
-		chroma.NameAttribute:    toChromaColor(colors.Accent1),
-		chroma.NameBuiltin:      toChromaColor(colors.Accent3),
-		chroma.NameClass:        toChromaColor(colors.Accent2) + " bold",
-		chroma.NameConstant:     toChromaColor(colors.Accent1),
-		chroma.NameDecorator:    toChromaColor(colors.Primary),
-		chroma.NameEntity:       toChromaColor(colors.Accent1),
-		chroma.NameException:    toChromaColor(colors.Error),
-		chroma.NameFunction:     toChromaColor(colors.Accent2),
-		chroma.NameLabel:        toChromaColor(colors.Primary),
-		chroma.NameNamespace:    toChromaColor(colors.Accent2),
-		chroma.NameTag:          toChromaColor(colors.Primary),
-		chroma.NameVariable:     toChromaColor(colors.Text),
+		chroma.Name:             toChromaColor(r, colors.Text),
+		chroma.NameAttribute:    toChromaColor(r, colors.Accent1),
+		chroma.NameBuiltin:      toChromaColor(r, colors.Accent3),
+		chroma.NameClass:        toChromaColor(r, colors.Accent2) + " bold",
+		chroma.NameConstant:     toChromaColor(r, colors.Accent1),
+		chroma.NameDecorator:    toChromaColor(r, colors.Primary),
+		chroma.NameEntity:       toChromaColor(r, colors.Accent1),
+		chroma.NameException:    toChromaColor(r, colors.Error),
+		chroma.NameFunction:     toChromaColor(r, colors.Accent2),
+		chroma.NameLabel:        toChromaColor(r, colors.Primary),
+		chroma.NameNamespace:    toChromaColor(r, colors.Accent2),
+		chroma.NameTag:          toChromaColor(r, colors.Primary),
+		chroma.NameVariable:     toChromaColor(r, colors.Text),
 
 		// Literals
-		chroma.LiteralString:         toChromaColor(colors.Accent3),
-		chroma.LiteralStringAffix:    toChromaColor(colors.Accent3),
-		chroma.LiteralStringBacktick: toChromaColor(colors.Accent3),
-		chroma.LiteralStringChar:     toChromaColor(colors.Accent3),
-		chroma.LiteralStringDelimiter: toChromaColor(colors.Accent3),
-		chroma.LiteralStringDoc:      toChromaColor(colors.TextMuted),
-		chroma.LiteralStringDouble:   toChromaColor(colors.Accent3),
-		chroma.LiteralStringEscape:   toChromaColor(colors.Accent1),
-		chroma.LiteralStringHeredoc:  toChromaColor(colors.Accent3),
-		chroma.LiteralStringInterpol: toChromaColor(colors.Accent1),
-		chroma.LiteralStringRegex:    toChromaColor(colors.Accent1),
-		chroma.LiteralStringSingle:   toChromaColor(colors.Accent3),
-
-		chroma.LiteralNumber:            toChromaColor(colors.Accent1),
-		chroma.LiteralNumberBin:         toChromaColor(colors.Accent1),
-		chroma.LiteralNumberFloat:       toChromaColor(colors.Accent1),
-		chroma.LiteralNumberHex:         toChromaColor(colors.Accent1),
-		chroma.LiteralNumberInteger:     toChromaColor(colors.Accent1),
-		chroma.LiteralNumberIntegerLong: toChromaColor(colors.Accent1),
-		chroma.LiteralNumberOct:         toChromaColor(colors.Accent1),
+		chroma.LiteralString:         toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringAffix:    toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringBacktick: toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringChar:     toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringDelimiter: toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringDoc:      toChromaColor(r, colors.TextMuted),
+		chroma.LiteralStringDouble:   toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringEscape:   toChromaColor(r, colors.Accent1),
+		chroma.LiteralStringHeredoc:  toChromaColor(r, colors.Accent3),
+		chroma.LiteralStringInterpol: toChromaColor(r, colors.Accent1),
+		chroma.LiteralStringRegex:    toChromaColor(r, colors.Accent1),
+		chroma.LiteralStringSingle:   toChromaColor(r, colors.Accent3),
+
+		chroma.LiteralNumber:            toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberBin:         toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberFloat:       toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberHex:         toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberInteger:     toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberIntegerLong: toChromaColor(r, colors.Accent1),
+		chroma.LiteralNumberOct:         toChromaColor(r, colors.Accent1),
 
 		// Operators
-		chroma.Operator:     toChromaColor(colors.Primary),
-		chroma.OperatorWord: toChromaColor(colors.Primary) + " bold",
+		chroma.Operator:     toChromaColor(r, colors.Primary),
+		chroma.OperatorWord: toChromaColor(r, colors.Primary) + " bold",
 
 		// Punctuation
-		chroma.Punctuation: toChromaColor(colors.TextMuted),
+		chroma.Punctuation: toChromaColor(r, colors.TextMuted),
 
 		// Comments
-		chroma.Comment:         toChromaColor(colors.TextDim) + " italic",
-		chroma.CommentHashbang: toChromaColor(colors.TextDim) + " italic",
-		chroma.CommentMultiline: toChromaColor(colors.TextDim) + " italic",
-		chroma.CommentPreproc:  toChromaColor(colors.TextMuted),
-		chroma.CommentSingle:   toChromaColor(colors.TextDim) + " italic",
-		chroma.CommentSpecial:  toChromaColor(colors.TextMuted) + " italic bold",
+		chroma.Comment:         toChromaColor(r, colors.TextDim) + " italic",
+		chroma.CommentHashbang: toChromaColor(r, colors.TextDim) + " italic",
+		chroma.CommentMultiline: toChromaColor(r, colors.TextDim) + " italic",
+		chroma.CommentPreproc:  toChromaColor(r, colors.TextMuted),
+		chroma.CommentSingle:   toChromaColor(r, colors.TextDim) + " italic",
+		chroma.CommentSpecial:  toChromaColor(r, colors.TextMuted) + " italic bold",
 
 		// Generic
-		chroma.Generic:        toChromaColor(colors.Text),
-		chroma.GenericDeleted: toChromaColor(colors.Error),
-		chroma.GenericEmph:    toChromaColor(colors.Text) + " italic",
-		chroma.GenericError:   toChromaColor(colors.Error),
-		chroma.GenericHeading: toChromaColor(colors.Primary) + " bold",
-		chroma.GenericInserted: toChromaColor(colors.Success),
-		chroma.GenericOutput:  toChromaColor(colors.TextMuted),
-		chroma.GenericPrompt:  toChromaColor(colors.Primary) + " bold",
-		chroma.GenericStrong:  toChromaColor(colors.Text) + " bold",
-		chroma.GenericSubheading: toChromaColor(colors.Accent2) + " bold",
-		chroma.GenericTraceback: toChromaColor(colors.Error),
+		chroma.Generic:        toChromaColor(r, colors.Text),
+		chroma.GenericDeleted: toChromaColor(r, colors.Error),
+		chroma.GenericEmph:    toChromaColor(r, colors.Text) + " italic",
+		chroma.GenericError:   toChromaColor(r, colors.Error),
+		chroma.GenericHeading: toChromaColor(r, colors.Primary) + " bold",
+		chroma.GenericInserted: toChromaColor(r, colors.Success),
+		chroma.GenericOutput:  toChromaColor(r, colors.TextMuted),
+		chroma.GenericPrompt:  toChromaColor(r, colors.Primary) + " bold",
+		chroma.GenericStrong:  toChromaColor(r, colors.Text) + " bold",
+		chroma.GenericSubheading: toChromaColor(r, colors.Accent2) + " bold",
+		chroma.GenericTraceback: toChromaColor(r, colors.Error),
 
 		// Errors
-		chroma.Error: toChromaColor(colors.Error) + " bold",
-	})
+		chroma.Error: toChromaColor(r, colors.Error) + " bold",
+	}
+
+	// Apply user-supplied overrides from the theme's JSON definition on
+	// top of the palette-derived entries above.
+	for token, value := range theme.Current.ChromaOverrides {
+		if tokenType, ok := chromaShortNames[token]; ok {
+			entries[tokenType] = value
+		}
+	}
+
+	degradeChromaEntries(entries, theme.ProfileFromRenderer(r))
+
+	return chroma.MustNewStyle("charm", entries)
+}
+
+// degradeChromaEntries downgrades a Chroma style for terminals with
+// limited color support, so highlighted code stays readable over SSH
+// sessions with reduced color support: backgrounds are dropped below
+// TrueColor/ANSI256, and ANSI16 terminals get every color snapped to the
+// nearest of the 16 standard ANSI colors. Ascii terminals keep only text
+// attributes (bold/italic/underline), matching how fx falls back to a
+// monochrome theme.
+func degradeChromaEntries(entries chroma.StyleEntries, p theme.Profile) {
+	if p == theme.TrueColor || p == theme.ANSI256 {
+		return
+	}
+	delete(entries, chroma.Background)
+	for token, value := range entries {
+		entries[token] = collapseStyleEntry(value, p)
+	}
 }
 
-// toChromaColor converts a lipgloss TerminalColor to a Chroma-compatible hex color string.
-// Chroma requires hex colors (e.g., "#FF00FF"), not ANSI codes.
-func toChromaColor(c lipgloss.TerminalColor) string {
+// collapseStyleEntry downgrades a single Chroma style-entry string
+// ("#hex [bold] [italic] [bg:#hex]") for profile p: backgrounds are
+// dropped, and on ANSI16 terminals hex colors are snapped to the nearest of
+// the 16 standard ANSI colors; on Ascii terminals colors are dropped
+// entirely and only text attributes survive.
+func collapseStyleEntry(value string, p theme.Profile) string {
+	var out []string
+	for _, field := range strings.Fields(value) {
+		switch {
+		case strings.HasPrefix(field, "bg:"):
+			continue
+		case strings.HasPrefix(field, "#"):
+			if p == theme.ANSI16 {
+				out = append(out, theme.NearestANSI16Hex(field))
+			}
+			// Ascii: drop the color, keep only attributes below.
+		default:
+			out = append(out, field)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// chromaShortNames maps Chroma/Pygments short token names (as used in
+// ThemeJSON's chromaOverrides) to the chroma.TokenType constants BuildChromaStyle
+// populates above.
+var chromaShortNames = map[string]chroma.TokenType{
+	"bg": chroma.Background, "ln": chroma.LineNumbers, "lnt": chroma.LineNumbersTable,
+
+	"k": chroma.Keyword, "kc": chroma.KeywordConstant, "kd": chroma.KeywordDeclaration,
+	"kn": chroma.KeywordNamespace, "kt": chroma.KeywordType,
+
+	"n": chroma.Name, "na": chroma.NameAttribute, "nb": chroma.NameBuiltin,
+	"nc": chroma.NameClass, "nd": chroma.NameDecorator, "ne": chroma.NameException,
+	"no": chroma.NameConstant,
+	"nf": chroma.NameFunction, "ni": chroma.NameEntity, "nl": chroma.NameLabel,
+	"nn": chroma.NameNamespace, "nt": chroma.NameTag, "nv": chroma.NameVariable,
+
+	"s": chroma.LiteralString, "sa": chroma.LiteralStringAffix, "sb": chroma.LiteralStringBacktick,
+	"sc": chroma.LiteralStringChar, "sd": chroma.LiteralStringDoc, "se": chroma.LiteralStringEscape,
+	"sh": chroma.LiteralStringHeredoc, "si": chroma.LiteralStringInterpol, "sr": chroma.LiteralStringRegex,
+	"s1": chroma.LiteralStringSingle, "s2": chroma.LiteralStringDouble,
+
+	"m": chroma.LiteralNumber, "mb": chroma.LiteralNumberBin, "mf": chroma.LiteralNumberFloat,
+	"mh": chroma.LiteralNumberHex, "mi": chroma.LiteralNumberInteger, "il": chroma.LiteralNumberIntegerLong,
+	"mo": chroma.LiteralNumberOct,
+
+	"o": chroma.Operator, "ow": chroma.OperatorWord, "p": chroma.Punctuation,
+
+	"c": chroma.Comment, "ch": chroma.CommentHashbang, "cm": chroma.CommentMultiline,
+	"cp": chroma.CommentPreproc, "c1": chroma.CommentSingle, "cs": chroma.CommentSpecial,
+
+	"g": chroma.Generic, "gd": chroma.GenericDeleted, "ge": chroma.GenericEmph,
+	"gr": chroma.GenericError, "gh": chroma.GenericHeading, "gi": chroma.GenericInserted,
+	"go": chroma.GenericOutput, "gp": chroma.GenericPrompt, "gs": chroma.GenericStrong,
+	"gu": chroma.GenericSubheading, "gt": chroma.GenericTraceback,
+
+	"err": chroma.Error,
+}
+
+// toChromaColor converts a lipgloss TerminalColor to a Chroma-compatible hex
+// color string. Chroma requires hex colors (e.g., "#FF00FF"), not ANSI
+// codes. r's detected background decides which branch of an
+// AdaptiveColor/CompleteAdaptiveColor to use, so highlighting matches the
+// terminal's actual light/dark mode instead of always assuming dark.
+func toChromaColor(r *lipgloss.Renderer, c lipgloss.TerminalColor) string {
 	// If it's a simple lipgloss.Color (string), check if it's hex or ANSI
 	if color, ok := c.(lipgloss.Color); ok {
 		colorStr := string(color)
@@ -111,12 +216,17 @@ func toChromaColor(c lipgloss.TerminalColor) string {
 		return ansi256ToHex(colorStr)
 	}
 
-	// For AdaptiveColor, use the dark variant
+	// For AdaptiveColor, pick the branch matching the renderer's detected
+	// background.
 	if adaptive, ok := c.(lipgloss.AdaptiveColor); ok {
-		if len(adaptive.Dark) > 0 && adaptive.Dark[0] == '#' {
-			return adaptive.Dark
+		variant := adaptive.Dark
+		if !r.HasDarkBackground() {
+			variant = adaptive.Light
 		}
-		return ansi256ToHex(adaptive.Dark)
+		if len(variant) > 0 && variant[0] == '#' {
+			return variant
+		}
+		return ansi256ToHex(variant)
 	}
 
 	// For CompleteColor, prefer TrueColor
@@ -130,40 +240,40 @@ func toChromaColor(c lipgloss.TerminalColor) string {
 		return ansi256ToHex(complete.ANSI)
 	}
 
-	// For CompleteAdaptiveColor, use dark TrueColor
+	// For CompleteAdaptiveColor, pick the branch matching the renderer's
+	// detected background, then prefer TrueColor within it.
 	if completeAdaptive, ok := c.(lipgloss.CompleteAdaptiveColor); ok {
-		if completeAdaptive.Dark.TrueColor != "" {
-			return completeAdaptive.Dark.TrueColor
+		variant := completeAdaptive.Dark
+		if !r.HasDarkBackground() {
+			variant = completeAdaptive.Light
+		}
+		if variant.TrueColor != "" {
+			return variant.TrueColor
 		}
-		if completeAdaptive.Dark.ANSI256 != "" {
-			return ansi256ToHex(completeAdaptive.Dark.ANSI256)
+		if variant.ANSI256 != "" {
+			return ansi256ToHex(variant.ANSI256)
 		}
-		return ansi256ToHex(completeAdaptive.Dark.ANSI)
+		return ansi256ToHex(variant.ANSI)
 	}
 
 	// Fallback
 	return "#FFFFFF"
 }
 
-// ansi256ToHex converts ANSI 256 color codes to approximate hex values.
-// This is a simplified mapping for common CharmDark colors.
+// ansi256ToHex converts a color string that might be an ANSI 256 index
+// ("212"), a raw hex color ("#7D56F4"), or an "rgb:RRGGBB" passthrough into
+// a hex color Chroma can consume.
 func ansi256ToHex(ansiCode string) string {
-	// Map of common ANSI codes to hex colors
-	ansiMap := map[string]string{
-		"212": "#FF87D7", // Pink (CharmPink)
-		"35":  "#00AF5F", // Teal (CharmTeal)
-		"99":  "#875FFF", // Violet (CharmViolet)
-		"63":  "#5F5FFF", // Indigo (CharmIndigo)
-		"8":   "#808080", // Gray
-		"7":   "#C0C0C0", // Light gray
-		"0":   "#000000", // Black
-		"15":  "#FFFFFF", // White
+	if len(ansiCode) > 0 && ansiCode[0] == '#' {
+		return ansiCode
 	}
-
-	if hex, ok := ansiMap[ansiCode]; ok {
-		return hex
+	if rgb, ok := strings.CutPrefix(ansiCode, "rgb:"); ok {
+		return "#" + strings.ToUpper(rgb)
 	}
 
-	// If no mapping, return a neutral gray
-	return "#888888"
+	code, err := strconv.Atoi(ansiCode)
+	if err != nil {
+		return "#888888"
+	}
+	return theme.ANSI256ToHex(code)
 }
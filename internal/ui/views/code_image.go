@@ -0,0 +1,269 @@
+package views
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/flight505/agentui/internal/theme/colorx"
+)
+
+// cellWidth/cellHeight are basicfont.Face7x13's glyph metrics, used to lay
+// out the rasterized code grid.
+const (
+	cellWidth  = 7
+	cellHeight = 13
+)
+
+// renderImage rasterizes the view's highlighted code to an image and wraps
+// it in the escape sequence backend's terminal graphics protocol expects.
+func (v *CodeView) renderImage(backend ImageProtocol) (string, error) {
+	img := v.rasterize()
+
+	switch backend {
+	case KittyBackend:
+		data, err := encodePNG(img)
+		if err != nil {
+			return "", err
+		}
+		return kittyEscape(data), nil
+	case ITermBackend:
+		data, err := encodePNG(img)
+		if err != nil {
+			return "", err
+		}
+		return itermEscape(data), nil
+	case SixelBackend:
+		return sixelEscape(img), nil
+	default:
+		return "", fmt.Errorf("renderImage: unsupported backend %v", backend)
+	}
+}
+
+// rasterize renders the view's ANSI-highlighted code into an RGBA image,
+// one basicfont.Face7x13 glyph per character, preserving each character's
+// Chroma-assigned foreground color.
+func (v *CodeView) rasterize() *image.RGBA {
+	lines := strings.Split(v.highlightCode(), "\n")
+	rows := make([][]styledRune, len(lines))
+	cols := 0
+	for i, line := range lines {
+		rows[i] = parseANSILine(line)
+		if len(rows[i]) > cols {
+			cols = len(rows[i])
+		}
+	}
+	if cols == 0 {
+		cols = 1
+	}
+	if len(rows) == 0 {
+		rows = [][]styledRune{nil}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, cols*cellWidth, len(rows)*cellHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	for row, line := range rows {
+		for col, cell := range line {
+			d := &font.Drawer{
+				Dst:  img,
+				Src:  image.NewUniform(cell.color),
+				Face: face,
+				Dot:  fixed.P(col*cellWidth, row*cellHeight+face.Ascent),
+			}
+			d.DrawString(string(cell.r))
+		}
+	}
+	return img
+}
+
+// styledRune is a single rasterized character plus the foreground color
+// its Chroma/ANSI styling assigned it.
+type styledRune struct {
+	r     rune
+	color color.Color
+}
+
+// parseANSILine walks a line of SGR-colored text (as formatters.TTY256
+// produces) into one styledRune per visible character, tracking the most
+// recently set foreground across "\x1b[...m" escapes.
+func parseANSILine(line string) []styledRune {
+	var out []styledRune
+	fg := color.Color(color.White)
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '\x1b' || i+1 >= len(runes) || runes[i+1] != '[' {
+			out = append(out, styledRune{r: runes[i], color: fg})
+			continue
+		}
+		j := i + 2
+		start := j
+		for j < len(runes) && runes[j] != 'm' {
+			j++
+		}
+		if c, ok := sgrForeground(string(runes[start:j])); ok {
+			fg = c
+		}
+		i = j
+	}
+	return out
+}
+
+// sgrForeground extracts a foreground color from the numeric codes of an
+// SGR escape (the part between "\x1b[" and "m"), understanding the
+// "38;5;N" 256-color form formatters.TTY256 emits.
+func sgrForeground(codes string) (color.Color, bool) {
+	parts := strings.Split(codes, ";")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] != "38" || i+2 >= len(parts) || parts[i+1] != "5" {
+			continue
+		}
+		n, err := strconv.Atoi(parts[i+2])
+		if err != nil {
+			continue
+		}
+		return hexToColor(colorx.ANSI256ToHex(n)), true
+	}
+	return nil, false
+}
+
+// hexToColor parses a "#RRGGBB" string into a color.Color, defaulting to
+// white on a malformed input.
+func hexToColor(hex string) color.Color {
+	r, g, b, ok := hexRGB(hex)
+	if !ok {
+		return color.White
+	}
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: 255}
+}
+
+// hexRGB parses a "#RRGGBB" string into its integer red/green/blue
+// components (0-255).
+func hexRGB(hex string) (r, g, b int, ok bool) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseInt(hex, 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF), true
+}
+
+// encodePNG encodes img as a base64 PNG payload for the kitty/iTerm2
+// inline-image escape sequences.
+func encodePNG(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// kittyEscape wraps base64-encoded PNG data in the kitty terminal graphics
+// protocol's transmit-and-display escape sequence, chunked to the
+// protocol's 4096-byte-per-escape limit.
+func kittyEscape(data string) string {
+	const chunkSize = 4096
+	var b strings.Builder
+	for len(data) > 0 {
+		n := chunkSize
+		if n > len(data) {
+			n = len(data)
+		}
+		chunk, rest := data[:n], data[n:]
+		data = rest
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		fmt.Fprintf(&b, "\x1b_Ga=T,f=100,m=%d;%s\x1b\\", more, chunk)
+	}
+	return b.String()
+}
+
+// itermEscape wraps base64-encoded PNG data in iTerm2's OSC 1337 inline
+// image escape sequence.
+func itermEscape(data string) string {
+	return fmt.Sprintf("\x1b]1337;File=inline=1:%s\a", data)
+}
+
+// sixelEscape renders img as a sixel stream using the 16-color ANSI
+// palette (via colorx.ANSI16Palette), a coarse but broadly-compatible
+// approximation rather than a full adaptive-palette quantizer.
+func sixelEscape(img image.Image) string {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	palette := colorx.ANSI16Palette()
+
+	indices := make([][]int, height)
+	for y := 0; y < height; y++ {
+		indices[y] = make([]int, width)
+		for x := 0; x < width; x++ {
+			indices[y][x] = nearestPaletteIndex(img.At(bounds.Min.X+x, bounds.Min.Y+y), palette)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1bPq")
+	for i, hex := range palette {
+		r, g, bl, _ := hexRGB(hex)
+		fmt.Fprintf(&b, "#%d;2;%d;%d;%d", i, r*100/255, g*100/255, bl*100/255)
+	}
+
+	for y0 := 0; y0 < height; y0 += 6 {
+		for c := range palette {
+			var row strings.Builder
+			used := false
+			for x := 0; x < width; x++ {
+				var mask byte
+				for dy := 0; dy < 6 && y0+dy < height; dy++ {
+					if indices[y0+dy][x] == c {
+						mask |= 1 << uint(dy)
+						used = true
+					}
+				}
+				row.WriteByte('?' + mask)
+			}
+			if used {
+				fmt.Fprintf(&b, "#%d%s$", c, row.String())
+			}
+		}
+		b.WriteString("-")
+	}
+	b.WriteString("\x1b\\")
+	return b.String()
+}
+
+// nearestPaletteIndex returns the index into palette closest to c in RGB
+// space.
+func nearestPaletteIndex(c color.Color, palette []string) int {
+	r, g, bl, _ := c.RGBA()
+	tr, tg, tb := int(r>>8), int(g>>8), int(bl>>8)
+
+	best := 0
+	bestDist := 1 << 30
+	for i, hex := range palette {
+		pr, pg, pb, _ := hexRGB(hex)
+		dr, dg, db := pr-tr, pg-tg, pb-tb
+		dist := dr*dr + dg*dg + db*db
+		if dist < bestDist {
+			bestDist = dist
+			best = i
+		}
+	}
+	return best
+}
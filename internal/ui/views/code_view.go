@@ -0,0 +1,260 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/flight505/agentui/internal/theme"
+)
+
+// CodeView renders a block of source code with Chroma syntax highlighting
+// inside the Charm CodeContainer/CodeTitle chrome, with optional line
+// numbers, called-out lines, unified-diff coloring, and a choice of
+// terminal image backend. It's a plain value (not a tea.Model): callers
+// build one with the SetX methods below and render it with View()
+// wherever they're composing a frame.
+type CodeView struct {
+	title       string
+	language    string
+	code        string
+	lineNumbers bool
+	width       int
+
+	highlightLines map[int]bool
+
+	diff    bool
+	oldCode string
+	newCode string
+
+	backend       ImageProtocol
+	backendForced bool
+
+	renderer *lipgloss.Renderer
+}
+
+// NewCodeView creates a CodeView bound to the default renderer, using the
+// ANSI (Chroma-highlighted text) backend until SetBackend or
+// DetectImageProtocol says otherwise.
+func NewCodeView() *CodeView {
+	return &CodeView{renderer: lipgloss.DefaultRenderer()}
+}
+
+// SetTitle sets the title shown in the code view's header bar.
+func (v *CodeView) SetTitle(title string) {
+	v.title = title
+}
+
+// SetLanguage sets the Chroma lexer name (e.g. "go", "python") highlightCode
+// tokenizes the code as.
+func (v *CodeView) SetLanguage(language string) {
+	v.language = language
+}
+
+// SetCode sets the source code to render. Calling SetCode clears any
+// SetDiff state, since the two are mutually exclusive content sources.
+func (v *CodeView) SetCode(code string) {
+	v.code = code
+	v.diff = false
+}
+
+// SetLineNumbers toggles a left-hand gutter of line numbers.
+func (v *CodeView) SetLineNumbers(show bool) {
+	v.lineNumbers = show
+}
+
+// SetWidth sets the rendered width in columns.
+func (v *CodeView) SetWidth(width int) {
+	v.width = width
+}
+
+// SetHighlightLines marks the given 1-indexed line numbers to be called
+// out (an accent gutter marker) on top of normal syntax highlighting, e.g.
+// to point at the lines an agent is proposing to edit.
+func (v *CodeView) SetHighlightLines(lines []int) {
+	set := make(map[int]bool, len(lines))
+	for _, n := range lines {
+		set[n] = true
+	}
+	v.highlightLines = set
+}
+
+// SetDiff renders a unified diff between oldCode and newCode instead of a
+// single code block: lines only in oldCode are prefixed with a "-" marker
+// colored theme.Colors.Error, lines only in newCode with a "+" marker
+// colored theme.Colors.Success, and unchanged lines with a blank column.
+// Only the marker takes the Error/Success color; the line text itself is
+// run through Chroma like any other code, so the diff keeps syntax
+// highlighting instead of going flat. This is what lets the widget be
+// reused for reviewing agent-proposed edits.
+func (v *CodeView) SetDiff(oldCode, newCode string) {
+	v.diff = true
+	v.oldCode = oldCode
+	v.newCode = newCode
+	v.code = newCode
+}
+
+// SetBackend overrides automatic image-protocol detection (see
+// DetectImageProtocol) so callers can force plain ANSI, kitty, iTerm, or
+// sixel rendering regardless of what the terminal advertises.
+func (v *CodeView) SetBackend(p ImageProtocol) {
+	v.backend = p
+	v.backendForced = true
+}
+
+// View renders the code view: a title bar, then the highlighted code
+// (through whichever backend is active), inside the Charm CodeContainer
+// chrome.
+func (v *CodeView) View() string {
+	styles := theme.Current.Styles
+
+	backend := v.backend
+	if !v.backendForced {
+		backend = DetectImageProtocol(v.renderer)
+	}
+
+	body := v.renderANSI()
+	if backend == KittyBackend || backend == ITermBackend || backend == SixelBackend {
+		if img, err := v.renderImage(backend); err == nil {
+			body = img
+		}
+		// Rasterization isn't guaranteed to succeed on every platform
+		// (e.g. no usable font); silently keep the ANSI body on error
+		// rather than showing nothing.
+	}
+
+	title := styles.CodeTitle.Render(v.title)
+	content := title + "\n" + body
+
+	container := styles.CodeContainer
+	if v.width > 0 {
+		container = container.Width(v.width)
+	}
+	return container.Render(content)
+}
+
+// renderANSI renders the highlighted code as plain ANSI text, prefixing
+// each line with the SetHighlightLines accent marker (if any lines are
+// marked) and the line-number gutter (if SetLineNumbers is on) — these
+// are independent, so a highlight-only CodeView still shows its callouts
+// without also turning on line numbers. Diff output interleaves
+// added/removed lines, so its own +/- gutter stands in for both.
+func (v *CodeView) renderANSI() string {
+	highlighted := v.highlightCode()
+	if v.diff || (!v.lineNumbers && len(v.highlightLines) == 0) {
+		return highlighted
+	}
+
+	lines := strings.Split(highlighted, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		lineNo := i + 1
+		if len(v.highlightLines) > 0 {
+			b.WriteString(v.marker(lineNo))
+		}
+		if v.lineNumbers {
+			b.WriteString(v.lineNumber(lineNo))
+		}
+		b.WriteByte(' ')
+		b.WriteString(line)
+		if i < len(lines)-1 {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// marker renders the SetHighlightLines accent column: "▌" for a called-out
+// line, a blank column otherwise.
+func (v *CodeView) marker(lineNo int) string {
+	if v.highlightLines[lineNo] {
+		return "▌"
+	}
+	return " "
+}
+
+// lineNumber renders the line-number gutter text for lineNo.
+func (v *CodeView) lineNumber(lineNo int) string {
+	return theme.Current.Styles.CodeTitle.Render(fmt.Sprintf("%3d", lineNo))
+}
+
+// highlightCode tokenizes the view's code with Chroma using v.language
+// (falling back to plaintext on an unrecognized language) and renders it
+// as ANSI text against the active theme's Chroma style. When SetDiff is
+// active, it instead renders the unified diff via diffLines.
+func (v *CodeView) highlightCode() string {
+	if v.diff {
+		return v.diffLines()
+	}
+	return v.highlightSource(v.code)
+}
+
+// highlightSource runs source through the Chroma lexer/formatter pair,
+// falling back to the raw source on any tokenization or formatting error.
+func (v *CodeView) highlightSource(source string) string {
+	lexer := lexers.Get(v.language)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	lexer = chroma.Coalesce(lexer)
+
+	iterator, err := lexer.Tokenise(nil, source)
+	if err != nil {
+		return source
+	}
+
+	var buf bytes.Buffer
+	if err := formatters.TTY256.Format(&buf, BuildChromaStyleFor(v.renderer), iterator); err != nil {
+		return source
+	}
+	return buf.String()
+}
+
+// diffLines highlights v.oldCode and v.newCode independently, then
+// interleaves them into a unified diff via diffLCS: lines only in oldCode
+// are prefixed "-" in theme.Colors.Error, lines only in newCode are
+// prefixed "+" in theme.Colors.Success, and lines common to both (matched
+// by position, not just membership, so a duplicate line moved or removed
+// elsewhere isn't mistaken for unchanged) get a blank column.
+func (v *CodeView) diffLines() string {
+	oldRaw := strings.Split(v.oldCode, "\n")
+	newRaw := strings.Split(v.newCode, "\n")
+	oldHighlighted := strings.Split(v.highlightSource(v.oldCode), "\n")
+	newHighlighted := strings.Split(v.highlightSource(v.newCode), "\n")
+
+	colors := theme.Current.Colors
+	minus := v.renderer.NewStyle().Foreground(colors.Error).Render("-")
+	plus := v.renderer.NewStyle().Foreground(colors.Success).Render("+")
+
+	var b strings.Builder
+	for _, op := range diffLCS(oldRaw, newRaw) {
+		switch op.kind {
+		case diffDelete:
+			fmt.Fprintf(&b, "%s %s\n", minus, lineAt(oldHighlighted, op.oldIndex, oldRaw))
+		case diffInsert:
+			fmt.Fprintf(&b, "%s %s\n", plus, lineAt(newHighlighted, op.newIndex, newRaw))
+		default:
+			fmt.Fprintf(&b, "  %s\n", lineAt(newHighlighted, op.newIndex, newRaw))
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// lineAt returns highlighted[i], falling back to raw[i] (and then "") if
+// i is out of range. Chroma's formatter is expected to emit exactly
+// len(strings.Split(source, "\n")) lines, but this guards against any
+// lexer/formatter path that doesn't, rather than panicking on a diff.
+func lineAt(highlighted []string, i int, raw []string) string {
+	if i >= 0 && i < len(highlighted) {
+		return highlighted[i]
+	}
+	if i >= 0 && i < len(raw) {
+		return raw[i]
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package views
+
+// diffOpKind identifies one line's role in a unified diff between two
+// line sequences.
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one line of a unified diff: either a line common to both
+// sequences (oldIndex and newIndex both valid), a deleted line (oldIndex
+// valid), or an inserted line (newIndex valid).
+type diffOp struct {
+	kind     diffOpKind
+	oldIndex int
+	newIndex int
+}
+
+// diffLCS computes a minimal line-level diff between oldLines and
+// newLines via the standard longest-common-subsequence algorithm, so
+// lines are matched by position in the sequence rather than mere set
+// membership — a line removed from one spot and present unchanged
+// elsewhere is reported as a delete (at its old position) and an insert
+// (at its new one), not silently treated as unchanged.
+func diffLCS(oldLines, newLines []string) []diffOp {
+	n, m := len(oldLines), len(newLines)
+
+	lengths := make([][]int, n+1)
+	for i := range lengths {
+		lengths[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case oldLines[i] == newLines[j]:
+			ops = append(ops, diffOp{kind: diffEqual, oldIndex: i, newIndex: j})
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			ops = append(ops, diffOp{kind: diffDelete, oldIndex: i})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: diffInsert, newIndex: j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{kind: diffDelete, oldIndex: i})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{kind: diffInsert, newIndex: j})
+	}
+	return ops
+}
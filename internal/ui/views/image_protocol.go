@@ -0,0 +1,49 @@
+package views
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ImageProtocol identifies which terminal graphics protocol CodeView
+// should render through.
+type ImageProtocol int
+
+const (
+	// ANSIBackend renders Chroma-highlighted text via ANSI escape codes —
+	// the only backend guaranteed to work everywhere, and CodeView's
+	// default.
+	ANSIBackend ImageProtocol = iota
+	// KittyBackend rasterizes highlighted code to a PNG and emits it via
+	// the kitty terminal graphics protocol.
+	KittyBackend
+	// ITermBackend rasterizes highlighted code to a PNG and emits it via
+	// iTerm2's inline image escape sequence.
+	ITermBackend
+	// SixelBackend rasterizes highlighted code to a sixel image, for
+	// xterm/mlterm and other sixel-capable terminals.
+	SixelBackend
+)
+
+// DetectImageProtocol returns the best ImageProtocol for r's terminal,
+// falling back to ANSIBackend when no graphics protocol can be detected.
+// r is accepted (rather than reading only the environment) so a future
+// capability probe can factor in the renderer's own color-profile
+// detection; today the decision is entirely env-var based, the same
+// signals kitty/iTerm2/WezTerm document for clients to probe for support.
+// An explicit CodeView.SetBackend always wins over detection.
+func DetectImageProtocol(r *lipgloss.Renderer) ImageProtocol {
+	term := os.Getenv("TERM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", strings.HasPrefix(term, "xterm-kitty"):
+		return KittyBackend
+	case os.Getenv("TERM_PROGRAM") == "iTerm.app", os.Getenv("TERM_PROGRAM") == "WezTerm":
+		return ITermBackend
+	case strings.Contains(term, "mlterm"), os.Getenv("COLORTERM") == "sixel":
+		return SixelBackend
+	default:
+		return ANSIBackend
+	}
+}